@@ -0,0 +1,97 @@
+package skhron
+
+// PutMany is a function which puts entries into the storage under a single lock acquisition.
+// Entries with zero Exp are put like with `Put`, others expire at Exp.
+// The queue is updated in bulk (see `scheduleMany`).
+// It returns an error for each entry (nil on success) in the order of entries,
+// e.g. ErrKeyLimit for new keys which do not fit into the storage.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) PutMany(entries []Entry[V]) []error {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	errs := make([]error, len(entries))
+	expiring := make([]Entry[V], 0, len(entries))
+	defaults := make(map[string]struct{}) // keys expiring after DefaultTTL
+
+	now := s.Clock.Now()
+	for i, e := range entries {
+		if errs[i] = s.admit(e.Key); errs[i] != nil {
+			continue
+		}
+
+		s.set(e.Key, e.Value)
+
+		switch {
+		case !e.Exp.IsZero():
+			expiring = append(expiring, e)
+			delete(defaults, e.Key)
+		case s.DefaultTTL > 0:
+			ttl := s.DefaultTTL
+			if !s.SlidingExpiration {
+				ttl = s.jitter(ttl, s.TTLJitter)
+			}
+			expiring = append(expiring, Entry[V]{Key: e.Key, Exp: now.Add(ttl)})
+			defaults[e.Key] = struct{}{}
+		}
+	}
+
+	s.scheduleMany(expiring)
+
+	if s.SlidingExpiration {
+		for key := range defaults {
+			s.ttlIndex[key].Slide = s.DefaultTTL
+		}
+	}
+
+	return errs
+}
+
+// GetMany is a function which fetches values of the keys under a single lock acquisition.
+// Missing keys are not present in the result.
+// Expiration of the keys with sliding TTL is extended.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) GetMany(keys []string) map[string]V {
+	s.mu.RLock()
+	values := make(map[string]V, len(keys))
+	var sliding []string
+	for _, key := range keys {
+		value, ok := s.Data.Get2(key)
+		s.missing(key, ok)
+		if ok {
+			values[key] = value
+			if s.sliding(key) {
+				sliding = append(sliding, key)
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(sliding) > 0 {
+		s.touch(sliding...)
+	}
+
+	return values
+}
+
+// DeleteMany is a function which deletes the keys under a single lock acquisition.
+// It returns the number of keys which were present in the storage.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) DeleteMany(keys []string) int {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for _, key := range keys {
+		_, value := s.Data.Get2(key)
+		_, coll := s.colls[key]
+		if value || coll {
+			s.remove(key, EventDelete)
+			deleted++
+		}
+	}
+
+	return deleted
+}
@@ -0,0 +1,73 @@
+package skhron
+
+import (
+	"container/heap"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPutMany(t *testing.T) {
+	s := New[int]()
+	s.PutTTL("existing", 0, time.Hour)
+
+	now := time.Now()
+	entries := []Entry[int]{
+		{Key: "forever", Value: 1},
+		{Key: "existing", Value: 2, Exp: now.Add(time.Minute)},
+	}
+	for i := 0; i < 100; i++ {
+		entries = append(entries, Entry[int]{Key: fmt.Sprintf("key%d", i), Value: i, Exp: now.Add(time.Duration(100-i) * time.Second)})
+	}
+
+	for i, err := range s.PutMany(entries) {
+		if err != nil {
+			t.Errorf("PutMany() entry %d error = %v", i, err)
+		}
+	}
+
+	for _, e := range entries {
+		if v, err := s.Get(e.Key); err != nil || v != e.Value {
+			t.Errorf("Get(%s) = %v, %v, want %v", e.Key, v, err, e.Value)
+		}
+	}
+
+	if s.TTLq.Len() != 101 {
+		t.Errorf("queue length = %d, want 101", s.TTLq.Len())
+	}
+
+	// the queue must stay a valid heap with a consistent index
+	for i, item := range *s.TTLq {
+		if item.index != i || s.ttlIndex[item.Key] != item {
+			t.Errorf("queue item %s has invalid index", item.Key)
+		}
+	}
+	for first := s.TTLq.peek(); s.TTLq.Len() > 0; {
+		item := heap.Pop(s.TTLq).(*expireItem)
+		if item.Exp.Before(first.Exp) {
+			t.Fatalf("queue is not ordered")
+		}
+		first = item
+	}
+}
+
+func TestGetManyDeleteMany(t *testing.T) {
+	s := New[string]()
+	s.Put("a", "1")
+	s.Put("b", "2")
+	s.Put("c", "3")
+
+	want := map[string]string{"a": "1", "c": "3"}
+	if got := s.GetMany([]string{"a", "c", "missing"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetMany() = %v, want %v", got, want)
+	}
+
+	if deleted := s.DeleteMany([]string{"a", "b", "missing"}); deleted != 2 {
+		t.Errorf("DeleteMany() = %d, want 2", deleted)
+	}
+
+	if s.Exists("a") || s.Exists("b") || !s.Exists("c") {
+		t.Errorf("DeleteMany() deleted wrong keys")
+	}
+}
@@ -0,0 +1,33 @@
+package skhron
+
+import "time"
+
+// Clock is an interface which is used by Skhron to read current time and to create timers.
+// It allows to replace the system clock in tests (see `skhrontest.FakeClock`).
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is an interface which mirrors the methods of time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// SystemClock is a Clock backed by the `time` package. It is the default clock.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time                         { return time.Now() }
+func (SystemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (SystemClock) NewTimer(d time.Duration) Timer         { return &systemTimer{time.NewTimer(d)} }
+
+type systemTimer struct {
+	t *time.Timer
+}
+
+func (t *systemTimer) C() <-chan time.Time        { return t.t.C }
+func (t *systemTimer) Stop() bool                 { return t.t.Stop() }
+func (t *systemTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
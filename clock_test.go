@@ -0,0 +1,65 @@
+package skhron_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/skhrontest"
+)
+
+func TestCleanUpFakeClock(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(skhron.WithClock[string](clock))
+
+	if err := storage.PutTTL("test", "hello world", time.Minute); err != nil {
+		t.Errorf("put failed: %v", err)
+	}
+
+	clock.Advance(59 * time.Second)
+	storage.CleanUp()
+	if !storage.Exists("test") {
+		t.Errorf("key expired too early")
+	}
+
+	clock.Advance(2 * time.Second)
+	storage.CleanUp()
+	if storage.Exists("test") {
+		t.Errorf("cleanup failed")
+	}
+}
+
+func TestPeriodicCleanupFakeClock(t *testing.T) {
+	dir := t.TempDir()
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(
+		skhron.WithClock[string](clock),
+		skhron.WithSnapshotDir[string](dir),
+		skhron.WithTempSnapshotDir[string](dir),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go storage.PeriodicCleanup(ctx, time.Second, done)
+
+	if err := storage.PutTTL("test", "hello world", 500*time.Millisecond); err != nil {
+		t.Errorf("put failed: %v", err)
+	}
+
+	clock.BlockUntil(1) // cleanup goroutine is waiting for its period
+	clock.Advance(time.Second)
+	clock.BlockUntil(1) // cleanup finished and is waiting for the next period
+
+	if storage.Exists("test") {
+		t.Errorf("cleanup failed")
+	}
+
+	cancel()
+	<-done
+
+	// the stopped process does not leave its timer behind
+	if n := clock.Waiters(); n != 0 {
+		t.Errorf("Waiters() after cleanup stopped = %d, want 0", n)
+	}
+}
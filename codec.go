@@ -0,0 +1,91 @@
+package skhron
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// ValueCodec is an interface which converts a single value of type V into bytes and back.
+// Skhron uses the codec everywhere a value leaves the process memory (e.g. snapshots),
+// so types which are not friendly to `encoding/json` can still be persisted faithfully.
+type ValueCodec[V any] interface {
+	Marshal(value V) ([]byte, error)
+	Unmarshal(data []byte) (V, error)
+}
+
+// JSONCodec is a codec which uses `encoding/json`. It is the default codec.
+type JSONCodec[V any] struct{}
+
+func (JSONCodec[V]) Marshal(value V) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec[V]) Unmarshal(data []byte) (V, error) {
+	var value V
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+// BytesCodec is a codec for Skhron[[]byte]. It stores bytes as is.
+type BytesCodec struct{}
+
+func (BytesCodec) Marshal(value []byte) ([]byte, error) {
+	return value, nil
+}
+
+func (BytesCodec) Unmarshal(data []byte) ([]byte, error) {
+	return bytes.Clone(data), nil
+}
+
+// StringCodec is a codec for Skhron[string]. It stores strings as raw bytes.
+type StringCodec struct{}
+
+func (StringCodec) Marshal(value string) ([]byte, error) {
+	return []byte(value), nil
+}
+
+func (StringCodec) Unmarshal(data []byte) (string, error) {
+	return string(data), nil
+}
+
+// binaryPointer is a constraint for pointer to V, which implements
+// both encoding.BinaryMarshaler and encoding.BinaryUnmarshaler.
+type binaryPointer[V any] interface {
+	*V
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// BinaryCodec is a codec for types implementing encoding.BinaryMarshaler
+// and encoding.BinaryUnmarshaler (e.g. `BinaryCodec[time.Time, *time.Time]{}`).
+type BinaryCodec[V any, P binaryPointer[V]] struct{}
+
+func (BinaryCodec[V, P]) Marshal(value V) ([]byte, error) {
+	return P(&value).MarshalBinary()
+}
+
+func (BinaryCodec[V, P]) Unmarshal(data []byte) (V, error) {
+	var value V
+	err := P(&value).UnmarshalBinary(data)
+	return value, err
+}
+
+// GobCodec is a codec which uses `encoding/gob`.
+// It supports interface values (registered with gob.Register) and types implementing gob.GobEncoder.
+type GobCodec[V any] struct{}
+
+func (GobCodec[V]) Marshal(value V) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec[V]) Unmarshal(data []byte) (V, error) {
+	var value V
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return value, err
+}
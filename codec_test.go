@@ -0,0 +1,153 @@
+package skhron
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type binaryStruct struct {
+	msg string
+	age int64
+}
+
+func (b binaryStruct) MarshalBinary() ([]byte, error) {
+	buf := binary.AppendVarint(nil, b.age)
+	return append(buf, b.msg...), nil
+}
+
+func (b *binaryStruct) UnmarshalBinary(data []byte) error {
+	age, n := binary.Varint(data)
+	if n <= 0 {
+		return errors.New("invalid age")
+	}
+	b.age, b.msg = age, string(data[n:])
+	return nil
+}
+
+func TestCodecs(t *testing.T) {
+	t.Run("bytes", func(t *testing.T) {
+		data, _ := BytesCodec{}.Marshal([]byte("hello"))
+		value, err := BytesCodec{}.Unmarshal(data)
+		if err != nil || !bytes.Equal(value, []byte("hello")) {
+			t.Errorf("bytes round trip failed: %v, %v", value, err)
+		}
+	})
+
+	t.Run("string", func(t *testing.T) {
+		data, _ := StringCodec{}.Marshal("hello")
+		value, err := StringCodec{}.Unmarshal(data)
+		if err != nil || value != "hello" {
+			t.Errorf("string round trip failed: %v, %v", value, err)
+		}
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		codec := BinaryCodec[binaryStruct, *binaryStruct]{}
+		data, err := codec.Marshal(binaryStruct{msg: "hello", age: 5})
+		if err != nil {
+			t.Fatalf("marshal failed: %v", err)
+		}
+		value, err := codec.Unmarshal(data)
+		if err != nil || value != (binaryStruct{msg: "hello", age: 5}) {
+			t.Errorf("binary round trip failed: %v, %v", value, err)
+		}
+	})
+
+	t.Run("gob", func(t *testing.T) {
+		codec := GobCodec[map[string][]int]{}
+		data, err := codec.Marshal(map[string][]int{"a": {1, 2}})
+		if err != nil {
+			t.Fatalf("marshal failed: %v", err)
+		}
+		value, err := codec.Unmarshal(data)
+		if err != nil || !reflect.DeepEqual(value, map[string][]int{"a": {1, 2}}) {
+			t.Errorf("gob round trip failed: %v, %v", value, err)
+		}
+	})
+}
+
+func TestSnapshotCodecRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	opts := []StorageOpt[binaryStruct]{
+		WithSnapshotDir[binaryStruct](dir),
+		WithTempSnapshotDir[binaryStruct](dir),
+		WithValueCodec[binaryStruct](BinaryCodec[binaryStruct, *binaryStruct]{}),
+	}
+
+	s := New(opts...)
+	s.Put("forever", binaryStruct{msg: "hello world", age: 5})
+	s.PutTTL("ttl", binaryStruct{msg: "bye", age: 7}, time.Hour)
+
+	if err := s.CreateSnapshot(); err != nil {
+		t.Fatalf("create snapshot failed: %v", err)
+	}
+
+	loaded := New(opts...)
+	if err := loaded.LoadSnapshot(); err != nil {
+		t.Fatalf("load snapshot failed: %v", err)
+	}
+
+	for key, want := range map[string]binaryStruct{
+		"forever": {msg: "hello world", age: 5},
+		"ttl":     {msg: "bye", age: 7},
+	} {
+		if got, err := loaded.Get(key); err != nil || got != want {
+			t.Errorf("Get(%s) = %v, %v, want %v", key, got, err, want)
+		}
+	}
+
+	if loaded.TTLq.Len() != 1 {
+		t.Errorf("expected 1 item in queue, got %d", loaded.TTLq.Len())
+	}
+}
+
+func TestSnapshotPlainJSON(t *testing.T) {
+	s := New[map[string]int]()
+	s.Put("key", map[string]int{"a": 1})
+	s.RPush("list", map[string]int{"b": 2})
+
+	encoded, err := s.MarshalJSON()
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	// values encoded with the default codec are readable JSON, not base64
+	var snapshot struct {
+		Encoding    string
+		Data        map[string]map[string]int
+		Collections map[string]struct{ List []map[string]int }
+	}
+	if err := json.Unmarshal(encoded, &snapshot); err != nil {
+		t.Fatalf("snapshot is not plain JSON: %v", err)
+	}
+	if snapshot.Encoding != encodingJSON || snapshot.Data["key"]["a"] != 1 || snapshot.Collections["list"].List[0]["b"] != 2 {
+		t.Errorf("unexpected snapshot: %s", encoded)
+	}
+}
+
+func TestSnapshotVersion2(t *testing.T) {
+	dir := t.TempDir()
+	snapshot := `{"version":2,"data":{"key":"eyJhIjoxfQ=="},"collections":{"list":{"type":1,"list":["eyJiIjoyfQ=="]}}}`
+	if err := os.WriteFile(filepath.Join(dir, "snapshot"+SkhronExtension), []byte(snapshot), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(WithSnapshotDir[map[string]int](dir))
+	if err := s.LoadSnapshot(); err != nil {
+		t.Fatalf("load snapshot failed: %v", err)
+	}
+
+	if v, err := s.Get("key"); err != nil || v["a"] != 1 {
+		t.Errorf("Get(key) = %v, %v, want map[a:1]", v, err)
+	}
+	if l, err := s.LRange("list", 0, -1); err != nil || len(l) != 1 || l[0]["b"] != 2 {
+		t.Errorf("LRange(list) = %v, %v, want [map[b:2]]", l, err)
+	}
+}
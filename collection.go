@@ -0,0 +1,269 @@
+package skhron
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ErrWrongType is returned (wrapped with the key) when an operation is applied to a key holding another type
+// (e.g. `LPush` to a key holding a plain value or a set, or `Get` of a key holding a list).
+var ErrWrongType = errors.New("wrong type")
+
+// CollectionType is a type of a collection stored under a key.
+type CollectionType int
+
+const (
+	// CollectionList is a list of values (see Skhron.LPush).
+	CollectionList CollectionType = iota + 1
+	// CollectionSet is a set of string members (see Skhron.SAdd).
+	CollectionSet
+	// CollectionHash is a map of fields to values (see Skhron.HSet).
+	CollectionHash
+	// CollectionZSet is a set of string members ordered by scores (see Skhron.ZAdd).
+	CollectionZSet
+)
+
+func (t CollectionType) String() string {
+	switch t {
+	case CollectionList:
+		return "list"
+	case CollectionSet:
+		return "set"
+	case CollectionHash:
+		return "hash"
+	case CollectionZSet:
+		return "zset"
+	default:
+		return "unknown"
+	}
+}
+
+// collection is a collection stored under a key. Only the field of its type is used.
+// Collections share the keyspace and the expiry queue with plain values,
+// but they are not visible to iteration and are not indexed.
+// Empty collections are removed.
+type collection[V any] struct {
+	kind CollectionType
+	list []V
+	set  map[string]struct{}
+	hash map[string]V
+	zset *sortedSet
+}
+
+func newCollection[V any](kind CollectionType) *collection[V] {
+	c := &collection[V]{kind: kind}
+	switch kind {
+	case CollectionSet:
+		c.set = make(map[string]struct{})
+	case CollectionHash:
+		c.hash = make(map[string]V)
+	case CollectionZSet:
+		c.zset = newSortedSet()
+	}
+	return c
+}
+
+// len is a function which returns the number of elements of the collection.
+func (c *collection[V]) len() int {
+	switch c.kind {
+	case CollectionList:
+		return len(c.list)
+	case CollectionSet:
+		return len(c.set)
+	case CollectionHash:
+		return len(c.hash)
+	case CollectionZSet:
+		return c.zset.len()
+	default:
+		return 0
+	}
+}
+
+// Type is a function which returns the type of the collection stored under the key.
+// Zero type is returned for missing keys and keys holding plain values.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) Type(key string) CollectionType {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if c, ok := s.colls[key]; ok && !s.expired(key, s.Clock.Now()) {
+		return c.kind
+	}
+	return 0
+}
+
+// readCollection is a function which returns the collection of the kind stored under the key,
+// or nil if the key is missing or has expired.
+// If the key holds another type, ErrWrongType is returned.
+// The caller must hold the lock (for reading at least).
+func (s *Skhron[V]) readCollection(key string, kind CollectionType) (*collection[V], error) {
+	if s.expired(key, s.Clock.Now()) {
+		return nil, nil
+	}
+
+	if c, ok := s.colls[key]; ok {
+		if c.kind != kind {
+			return nil, wrongType(key)
+		}
+		return c, nil
+	}
+
+	if _, ok := s.Data.Get2(key); ok {
+		return nil, wrongType(key)
+	}
+
+	return nil, nil
+}
+
+// writeCollection is a function which returns the collection of the kind stored under the key for modification.
+// A collection which has expired, but has not been removed by cleanup yet, is removed first.
+// If `create` is true, a missing collection is created (it does not expire), otherwise nil is returned.
+// If the key holds another type, ErrWrongType is returned.
+// If the key is new and the storage is full (skhron.WithMaxKeys option), ErrKeyLimit is returned.
+// The caller must hold the write lock and call `changed` after the modification.
+func (s *Skhron[V]) writeCollection(key string, kind CollectionType, create bool) (*collection[V], error) {
+	if s.expired(key, s.Clock.Now()) {
+		s.remove(key, EventExpire)
+	}
+
+	c, err := s.readCollection(key, kind)
+	if err != nil || c != nil || !create {
+		return c, err
+	}
+
+	if err := s.admit(key); err != nil {
+		return nil, err
+	}
+
+	delete(s.negatives, key)
+	s.unschedule(key)
+
+	c = newCollection[V](kind)
+	s.colls[key] = c
+
+	return c, nil
+}
+
+// changed is a function which updates the version of the modified collection and notifies watchers
+// (EventPut with zero value). An empty collection is removed.
+// The caller must hold the write lock.
+func (s *Skhron[V]) changed(key string, c *collection[V]) {
+	if c.len() == 0 {
+		s.remove(key, EventDelete)
+		return
+	}
+
+	s.stats.puts.Add(1)
+	s.bump(key)
+}
+
+// bump is a function which updates the version of the modified collection and notifies watchers.
+// The caller must hold the write lock.
+func (s *Skhron[V]) bump(key string) {
+	s.seq++
+	s.versions[key] = s.seq
+
+	s.events.publish(Event[V]{Type: EventPut, Key: key, Version: s.seq})
+}
+
+// wrongType is a function which creates an error for a key holding another type.
+func wrongType(key string) error {
+	return fmt.Errorf("%w: %s", ErrWrongType, key)
+}
+
+// snapshotCollection is a collection in the snapshot. Values are encoded like values of keys (see `encodeSnapshotValue`).
+type snapshotCollection struct {
+	Type CollectionType             `json:"type"`
+	List []json.RawMessage          `json:"list,omitempty"`
+	Set  []string                   `json:"set,omitempty"`
+	Hash map[string]json.RawMessage `json:"hash,omitempty"`
+	ZSet map[string]snapshotScore   `json:"zset,omitempty"`
+}
+
+// encodeCollections is a function which converts collections into their snapshot form.
+// The caller must hold the lock.
+func (s *Skhron[V]) encodeCollections() (map[string]snapshotCollection, error) {
+	encoded := make(map[string]snapshotCollection, len(s.colls))
+
+	for key, c := range s.colls {
+		sc := snapshotCollection{Type: c.kind}
+
+		switch c.kind {
+		case CollectionList:
+			for _, value := range c.list {
+				data, err := s.encodeSnapshotValue(value)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode list %q: %w", key, err)
+				}
+				sc.List = append(sc.List, data)
+			}
+		case CollectionSet:
+			sc.Set = slices.Sorted(maps.Keys(c.set))
+		case CollectionHash:
+			sc.Hash = make(map[string]json.RawMessage, len(c.hash))
+			for field, value := range c.hash {
+				data, err := s.encodeSnapshotValue(value)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode field %q of hash %q: %w", field, key, err)
+				}
+				sc.Hash[field] = data
+			}
+		case CollectionZSet:
+			sc.ZSet = make(map[string]snapshotScore, len(c.zset.scores))
+			for member, score := range c.zset.scores {
+				sc.ZSet[member] = snapshotScore(score)
+			}
+		}
+
+		encoded[key] = sc
+	}
+
+	return encoded, nil
+}
+
+// decodeCollections is a function which restores collections from their snapshot form.
+func (s *Skhron[V]) decodeCollections(version int, encoding string, encoded map[string]snapshotCollection) (map[string]*collection[V], error) {
+	colls := make(map[string]*collection[V], len(encoded))
+
+	for key, sc := range encoded {
+		c := newCollection[V](sc.Type)
+
+		switch sc.Type {
+		case CollectionList:
+			for _, data := range sc.List {
+				value, err := s.decodeSnapshotValue(version, encoding, data)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode list %q: %w", key, err)
+				}
+				c.list = append(c.list, value)
+			}
+		case CollectionSet:
+			for _, member := range sc.Set {
+				c.set[member] = struct{}{}
+			}
+		case CollectionHash:
+			for field, data := range sc.Hash {
+				value, err := s.decodeSnapshotValue(version, encoding, data)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode field %q of hash %q: %w", field, key, err)
+				}
+				c.hash[field] = value
+			}
+		case CollectionZSet:
+			for member, score := range sc.ZSet {
+				c.zset.add(member, float64(score))
+			}
+		default:
+			return nil, fmt.Errorf("unknown type of collection %q: %d", key, sc.Type)
+		}
+
+		if c.len() > 0 {
+			colls[key] = c
+		}
+	}
+
+	return colls, nil
+}
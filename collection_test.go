@@ -0,0 +1,422 @@
+package skhron_test
+
+import (
+	"errors"
+	"maps"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/skhrontest"
+)
+
+func TestLists(t *testing.T) {
+	storage := skhron.New[string]()
+
+	storage.RPush("list", "b", "c")
+	if n, err := storage.LPush("list", "a", "z"); err != nil || n != 4 {
+		t.Fatalf("LPush = %d, %v, want 4, nil", n, err)
+	}
+
+	if got, _ := storage.LRange("list", 0, -1); !slices.Equal(got, []string{"z", "a", "b", "c"}) {
+		t.Errorf("LRange(0, -1) = %v", got)
+	}
+	if got, _ := storage.LRange("list", -2, 10); !slices.Equal(got, []string{"b", "c"}) {
+		t.Errorf("LRange(-2, 10) = %v", got)
+	}
+	if got, _ := storage.LRange("list", 3, 1); len(got) != 0 {
+		t.Errorf("LRange(3, 1) = %v, want empty", got)
+	}
+
+	if v, err := storage.LPop("list"); err != nil || v != "z" {
+		t.Errorf("LPop = %q, %v, want z, nil", v, err)
+	}
+	if v, err := storage.RPop("list"); err != nil || v != "c" {
+		t.Errorf("RPop = %q, %v, want c, nil", v, err)
+	}
+	if n, _ := storage.LLen("list"); n != 2 {
+		t.Errorf("LLen = %d, want 2", n)
+	}
+
+	storage.LPop("list")
+	storage.LPop("list")
+	if storage.Exists("list") {
+		t.Error("empty list is not removed")
+	}
+	if _, err := storage.LPop("list"); !errors.Is(err, skhron.ErrNoSuchKey) {
+		t.Errorf("LPop of missing list = %v, want %v", err, skhron.ErrNoSuchKey)
+	}
+}
+
+func TestSetsAndHashes(t *testing.T) {
+	storage := skhron.New[int]()
+
+	if n, _ := storage.SAdd("set", "b", "a", "b"); n != 2 {
+		t.Errorf("SAdd = %d, want 2", n)
+	}
+	if n, _ := storage.SAdd("set", "a", "c"); n != 1 {
+		t.Errorf("SAdd = %d, want 1", n)
+	}
+	if got, _ := storage.SMembers("set"); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("SMembers = %v", got)
+	}
+	if ok, _ := storage.SIsMember("set", "b"); !ok {
+		t.Error("SIsMember(b) = false")
+	}
+	if n, _ := storage.SRem("set", "b", "x"); n != 1 {
+		t.Errorf("SRem = %d, want 1", n)
+	}
+	if n, _ := storage.SCard("set"); n != 2 {
+		t.Errorf("SCard = %d, want 2", n)
+	}
+
+	if created, _ := storage.HSet("hash", "x", 1); !created {
+		t.Error("HSet of new field = false")
+	}
+	if created, _ := storage.HSet("hash", "x", 2); created {
+		t.Error("HSet of existing field = true")
+	}
+	storage.HSet("hash", "y", 3)
+	if v, err := storage.HGet("hash", "x"); err != nil || v != 2 {
+		t.Errorf("HGet(x) = %d, %v, want 2, nil", v, err)
+	}
+	if _, err := storage.HGet("hash", "z"); !errors.Is(err, skhron.ErrNoSuchKey) {
+		t.Errorf("HGet(z) = %v, want %v", err, skhron.ErrNoSuchKey)
+	}
+	if got, _ := storage.HGetAll("hash"); !maps.Equal(got, map[string]int{"x": 2, "y": 3}) {
+		t.Errorf("HGetAll = %v", got)
+	}
+	if n, _ := storage.HDel("hash", "x", "y"); n != 2 {
+		t.Errorf("HDel = %d, want 2", n)
+	}
+	if storage.Exists("hash") {
+		t.Error("empty hash is not removed")
+	}
+}
+
+func TestSortedSets(t *testing.T) {
+	storage := skhron.New[int]()
+
+	storage.ZAdd("zset", 3, "c")
+	storage.ZAdd("zset", 1, "a")
+	storage.ZAdd("zset", 2, "b2")
+	storage.ZAdd("zset", 2, "b1")
+	if added, _ := storage.ZAdd("zset", 5, "c"); added {
+		t.Error("ZAdd of existing member = true")
+	}
+	if score, _ := storage.ZIncrBy("zset", -4.5, "c"); score != 0.5 {
+		t.Errorf("ZIncrBy = %v, want 0.5", score)
+	}
+
+	got, err := storage.ZRangeByScore("zset", 0, 2)
+	want := []skhron.ZMember{{"c", 0.5}, {"a", 1}, {"b1", 2}, {"b2", 2}}
+	if err != nil || !slices.Equal(got, want) {
+		t.Errorf("ZRangeByScore(0, 2) = %v, %v, want %v", got, err, want)
+	}
+
+	if score, err := storage.ZScore("zset", "b2"); err != nil || score != 2 {
+		t.Errorf("ZScore(b2) = %v, %v, want 2, nil", score, err)
+	}
+	if n, _ := storage.ZRem("zset", "a", "b1"); n != 2 {
+		t.Errorf("ZRem = %d, want 2", n)
+	}
+	if n, _ := storage.ZCard("zset"); n != 2 {
+		t.Errorf("ZCard = %d, want 2", n)
+	}
+}
+
+func TestCollectionNoopWrites(t *testing.T) {
+	storage := skhron.New[int]()
+
+	storage.RPush("list", 1)
+	storage.SAdd("set", "a")
+	storage.ZAdd("zset", 1, "a")
+
+	// writes which change nothing do not bump versions, so they do not conflict with transactions
+	err := storage.Txn(func(tx *skhron.Tx[int]) error {
+		tx.Watch("list", "set", "zset")
+
+		storage.LPush("list")
+		storage.RPush("list")
+		storage.SAdd("set", "a")
+		storage.ZAdd("zset", 1, "a")
+		storage.ZIncrBy("zset", 0, "a")
+
+		tx.Put("done", 1)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Txn() after no-op writes = %v, want nil", err)
+	}
+
+	err = storage.Txn(func(tx *skhron.Tx[int]) error {
+		tx.Watch("zset")
+		storage.ZAdd("zset", 2, "a")
+		return nil
+	})
+	if !errors.Is(err, skhron.ErrConflict) {
+		t.Errorf("Txn() after ZAdd with a new score = %v, want %v", err, skhron.ErrConflict)
+	}
+}
+
+func TestCollectionTypes(t *testing.T) {
+	storage := skhron.New[int]()
+
+	storage.Put("value", 1)
+	storage.RPush("list", 1)
+
+	if _, err := storage.RPush("value", 1); !errors.Is(err, skhron.ErrWrongType) {
+		t.Errorf("RPush to value = %v, want %v", err, skhron.ErrWrongType)
+	}
+	if _, err := storage.SAdd("list", "a"); !errors.Is(err, skhron.ErrWrongType) {
+		t.Errorf("SAdd to list = %v, want %v", err, skhron.ErrWrongType)
+	}
+	if _, err := storage.Get("list"); !errors.Is(err, skhron.ErrWrongType) {
+		t.Errorf("Get of list = %v, want %v", err, skhron.ErrWrongType)
+	}
+
+	if storage.Type("list") != skhron.CollectionList || storage.Type("value") != 0 {
+		t.Errorf("Type(list), Type(value) = %v, %v", storage.Type("list"), storage.Type("value"))
+	}
+	if storage.Len() != 2 {
+		t.Errorf("Len = %d, want 2", storage.Len())
+	}
+
+	// a value replaces a collection
+	storage.Put("list", 2)
+	if v, err := storage.Get("list"); err != nil || v != 2 {
+		t.Errorf("Get(list) = %d, %v, want 2, nil", v, err)
+	}
+
+	limited := skhron.New(skhron.WithMaxKeys[int](1))
+	limited.SAdd("a", "x")
+	if _, err := limited.SAdd("b", "x"); !errors.Is(err, skhron.ErrKeyLimit) {
+		t.Errorf("SAdd over limit = %v, want %v", err, skhron.ErrKeyLimit)
+	}
+	if _, err := limited.SAdd("a", "y"); err != nil {
+		t.Errorf("SAdd to existing set = %v", err)
+	}
+}
+
+func TestCollectionExpiryAndSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts := []skhron.StorageOpt[string]{
+		skhron.WithClock[string](clock),
+		skhron.WithSnapshotDir[string](dir),
+		skhron.WithTempSnapshotDir[string](dir),
+	}
+	storage := skhron.New(opts...)
+
+	storage.RPush("list", "a", "b")
+	storage.SAdd("set", "x")
+	storage.HSet("hash", "f", "v")
+	storage.ZAdd("zset", 1.5, "m")
+	storage.Put("value", "plain")
+
+	if !storage.Expire("set", time.Minute) || !storage.Expire("list", time.Hour) {
+		t.Fatal("Expire of collections = false")
+	}
+	if storage.Expire("missing", time.Minute) {
+		t.Error("Expire of missing key = true")
+	}
+	if ttl, err := storage.TTL("set"); err != nil || ttl != time.Minute {
+		t.Errorf("TTL(set) = %v, %v, want 1m, nil", ttl, err)
+	}
+	if !storage.Persist("list") {
+		t.Error("Persist(list) = false")
+	}
+	if ttl, err := storage.TTL("list"); err != nil || ttl != 0 {
+		t.Errorf("TTL(list) = %v, %v, want 0, nil", ttl, err)
+	}
+
+	if err := storage.CreateSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := skhron.New(opts...)
+	if err := restored.LoadSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := restored.LRange("list", 0, -1); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("restored list = %v", got)
+	}
+	if got, _ := restored.HGet("hash", "f"); got != "v" {
+		t.Errorf("restored hash field = %q", got)
+	}
+	if got, _ := restored.ZScore("zset", "m"); got != 1.5 {
+		t.Errorf("restored zset score = %v", got)
+	}
+	if got, _ := restored.Get("value"); got != "plain" {
+		t.Errorf("restored value = %q", got)
+	}
+
+	clock.Advance(time.Minute + time.Second)
+	if n, _ := restored.SCard("set"); n != 0 {
+		t.Errorf("SCard of expired set = %d, want 0", n)
+	}
+	restored.CleanUpN(0)
+	if restored.Exists("set") {
+		t.Error("expired set is not removed")
+	}
+	if restored.Len() != 4 {
+		t.Errorf("Len = %d, want 4", restored.Len())
+	}
+}
+
+func TestHashFieldExpiry(t *testing.T) {
+	dir := t.TempDir()
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts := []skhron.StorageOpt[string]{
+		skhron.WithClock[string](clock),
+		skhron.WithSnapshotDir[string](dir),
+		skhron.WithTempSnapshotDir[string](dir),
+	}
+	storage := skhron.New(opts...)
+
+	storage.HSet("user", "name", "alice")
+	storage.HSet("user", "otp", "123456")
+	storage.HSet("session", "token", "t")
+
+	if ok, err := storage.HExpire("user", "otp", time.Minute); !ok || err != nil {
+		t.Fatalf("HExpire(user, otp) = %v, %v, want true, nil", ok, err)
+	}
+	if ok, _ := storage.HExpire("user", "missing", time.Minute); ok {
+		t.Error("HExpire of missing field = true")
+	}
+	storage.HExpire("session", "token", 2*time.Minute)
+
+	if ttl, err := storage.HTTL("user", "otp"); err != nil || ttl != time.Minute {
+		t.Errorf("HTTL(user, otp) = %v, %v, want 1m, nil", ttl, err)
+	}
+	if ttl, err := storage.HTTL("user", "name"); err != nil || ttl != 0 {
+		t.Errorf("HTTL(user, name) = %v, %v, want 0, nil", ttl, err)
+	}
+
+	if err := storage.CreateSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+	restored := skhron.New(opts...)
+	if err := restored.LoadSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []*skhron.Skhron[string]{storage, restored} {
+		clock.Set(time.Date(2024, 1, 1, 0, 1, 1, 0, time.UTC))
+
+		// expired fields are hidden before cleanup
+		if _, err := s.HGet("user", "otp"); !errors.Is(err, skhron.ErrNoSuchKey) {
+			t.Errorf("HGet of expired field = %v, want %v", err, skhron.ErrNoSuchKey)
+		}
+		if n, _ := s.HLen("user"); n != 1 {
+			t.Errorf("HLen = %d, want 1", n)
+		}
+
+		if n := s.CleanUpN(0); n != 1 {
+			t.Errorf("CleanUpN = %d, want 1", n)
+		}
+		if got, _ := s.HGetAll("user"); !maps.Equal(got, map[string]string{"name": "alice"}) {
+			t.Errorf("HGetAll after cleanup = %v", got)
+		}
+
+		// the hash is removed with its last field
+		clock.Advance(time.Minute)
+		s.CleanUpN(0)
+		if s.Exists("session") {
+			t.Error("hash without fields is not removed")
+		}
+	}
+
+	// deleted fields and hashes do not leave items in the queue
+	storage.HSet("user", "otp", "654321")
+	storage.HExpire("user", "otp", time.Minute)
+	storage.HDel("user", "otp")
+	storage.HSet("user", "otp", "000000")
+	storage.HExpire("user", "name", time.Minute)
+	storage.Delete("user")
+	storage.HSet("user", "name", "bob")
+
+	clock.Advance(2 * time.Minute)
+	if n := storage.CleanUpN(0); n != 0 {
+		t.Errorf("CleanUpN after deletes = %d, want 0", n)
+	}
+	if got, _ := storage.HGet("user", "name"); got != "bob" {
+		t.Errorf("HGet(user, name) = %q, want bob", got)
+	}
+}
+
+func TestHashEmptyFieldExpiry(t *testing.T) {
+	dir := t.TempDir()
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts := []skhron.StorageOpt[int]{
+		skhron.WithClock[int](clock),
+		skhron.WithSnapshotDir[int](dir),
+		skhron.WithTempSnapshotDir[int](dir),
+	}
+	storage := skhron.New(opts...)
+
+	// an empty field name is valid, its item must not be taken for the item of the key
+	storage.HSet("h", "", 1)
+	storage.HSet("h", "x", 2)
+	storage.Expire("h", time.Hour)
+	storage.HExpire("h", "", time.Second)
+
+	if err := storage.CreateSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+	restored := skhron.New(opts...)
+	if err := restored.LoadSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(time.Minute)
+	for _, s := range []*skhron.Skhron[int]{storage, restored} {
+		if n := s.CleanUpN(0); n != 1 {
+			t.Errorf("CleanUpN = %d, want 1", n)
+		}
+		if got, _ := s.HGetAll("h"); !maps.Equal(got, map[string]int{"x": 2}) {
+			t.Errorf("HGetAll after cleanup = %v", got)
+		}
+		if ttl, err := s.TTL("h"); err != nil || ttl != time.Hour-time.Minute {
+			t.Errorf("TTL(h) = %v, %v, want 59m, nil", ttl, err)
+		}
+	}
+}
+
+func TestSortedSetInfiniteScores(t *testing.T) {
+	dir := t.TempDir()
+	opts := []skhron.StorageOpt[int]{
+		skhron.WithSnapshotDir[int](dir),
+		skhron.WithTempSnapshotDir[int](dir),
+	}
+	storage := skhron.New(opts...)
+
+	storage.ZAdd("zset", math.Inf(1), "top")
+	storage.ZAdd("zset", math.Inf(-1), "bottom")
+	storage.ZAdd("zset", 1, "middle")
+
+	if _, err := storage.ZAdd("zset", math.NaN(), "nan"); !errors.Is(err, skhron.ErrInvalidScore) {
+		t.Errorf("ZAdd with NaN score = %v, want %v", err, skhron.ErrInvalidScore)
+	}
+	if _, err := storage.ZIncrBy("zset", math.Inf(-1), "top"); !errors.Is(err, skhron.ErrInvalidScore) {
+		t.Errorf("ZIncrBy to NaN score = %v, want %v", err, skhron.ErrInvalidScore)
+	}
+
+	// infinite scores survive snapshots
+	if err := storage.CreateSnapshot(); err != nil {
+		t.Fatalf("create snapshot failed: %v", err)
+	}
+	restored := skhron.New(opts...)
+	if err := restored.LoadSnapshot(); err != nil {
+		t.Fatalf("load snapshot failed: %v", err)
+	}
+
+	got, err := restored.ZRangeByScore("zset", math.Inf(-1), math.Inf(1))
+	want := []skhron.ZMember{{"bottom", math.Inf(-1)}, {"middle", 1}, {"top", math.Inf(1)}}
+	if err != nil || !slices.Equal(got, want) {
+		t.Errorf("restored ZRangeByScore = %v, %v, want %v", got, err, want)
+	}
+}
@@ -0,0 +1,134 @@
+package skhron
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrOverflow is returned when an increment overflows int64.
+var ErrOverflow = errors.New("integer overflow")
+
+// CounterOpt is an option of a counter (see NewCounter).
+type CounterOpt func(c *counterConfig)
+
+type counterConfig struct {
+	floor   int64
+	ceiling int64
+}
+
+// WithFloor sets the minimum value of counters: decrements below it are clamped to it.
+func WithFloor(floor int64) CounterOpt {
+	return func(c *counterConfig) {
+		c.floor = floor
+	}
+}
+
+// WithCeiling sets the maximum value of counters: increments above it are clamped to it.
+func WithCeiling(ceiling int64) CounterOpt {
+	return func(c *counterConfig) {
+		c.ceiling = ceiling
+	}
+}
+
+// Counter is a set of atomic integer counters stored in Skhron[int64].
+// Every operation is a single read-modify-write under the storage lock,
+// so concurrent increments are never lost.
+type Counter struct {
+	s      *Skhron[int64]
+	config counterConfig
+}
+
+// NewCounter is a function which creates counters stored in `s`.
+// By default counters are not clamped (the floor is math.MinInt64, the ceiling is math.MaxInt64).
+func NewCounter(s *Skhron[int64], opts ...CounterOpt) *Counter {
+	config := counterConfig{floor: math.MinInt64, ceiling: math.MaxInt64}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return &Counter{s: s, config: config}
+}
+
+// Incr is a function which increments the counter by one (see `IncrBy`).
+func (c *Counter) Incr(key string) (int64, error) {
+	return c.IncrBy(key, 1, 0)
+}
+
+// Decr is a function which decrements the counter by one (see `IncrBy`).
+func (c *Counter) Decr(key string) (int64, error) {
+	return c.IncrBy(key, -1, 0)
+}
+
+// DecrBy is a function which decrements the counter by `delta` (see `IncrBy`).
+func (c *Counter) DecrBy(key string, delta int64, ttlIfNew time.Duration) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, fmt.Errorf("%w: %s", ErrOverflow, key)
+	}
+	return c.IncrBy(key, -delta, ttlIfNew)
+}
+
+// IncrBy is a function which adds `delta` to the counter and returns the new value.
+// A missing (or expired) counter starts from zero and expires after `ttlIfNew`, if it is positive,
+// or after `DefaultTTL` of the storage, if it is set (skhron.WithDefaultTTL option).
+// An existing counter keeps its expiration.
+// The result is clamped to the floor and the ceiling (skhron.WithFloor and skhron.WithCeiling options).
+// If the result does not fit into int64, the counter is not changed and ErrOverflow is returned.
+// If the key holds a collection, ErrWrongType is returned.
+// If the counter is new and the storage is full (skhron.WithMaxKeys option), ErrKeyLimit is returned.
+// This function locks mutex for its operations.
+func (c *Counter) IncrBy(key string, delta int64, ttlIfNew time.Duration) (int64, error) {
+	s := c.s
+
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Clock.Now()
+
+	current, ok := s.Data.Get2(key)
+	if s.expired(key, now) {
+		s.remove(key, EventExpire)
+		current, ok = 0, false
+	}
+
+	if _, coll := s.colls[key]; coll {
+		return 0, wrongType(key)
+	}
+
+	if !ok {
+		if err := s.admit(key); err != nil {
+			return 0, err
+		}
+	}
+
+	value := current + delta
+	if (delta > 0 && value < current) || (delta < 0 && value > current) {
+		return current, fmt.Errorf("%w: %s", ErrOverflow, key)
+	}
+	value = min(max(value, c.config.floor), c.config.ceiling)
+
+	s.set(key, value)
+	if !ok {
+		if ttlIfNew > 0 {
+			s.schedule(key, now.Add(ttlIfNew))
+		} else {
+			s.scheduleDefault(key, now)
+		}
+	}
+
+	return value, nil
+}
+
+// Value is a function which returns the value of the counter (zero if it is missing).
+// This function locks mutex (for reading) for its operations.
+func (c *Counter) Value(key string) int64 {
+	value, _, _ := c.s.lookup(key)
+	return value
+}
+
+// Reset is a function which deletes the counter.
+func (c *Counter) Reset(key string) {
+	c.s.Delete(key)
+}
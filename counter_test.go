@@ -0,0 +1,85 @@
+package skhron_test
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/skhrontest"
+)
+
+func TestCounterConcurrentIncrements(t *testing.T) {
+	counter := skhron.NewCounter(skhron.New[int64]())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				counter.Incr("hits")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v := counter.Value("hits"); v != 2000 {
+		t.Errorf("Value(hits) = %d, want 2000", v)
+	}
+}
+
+func TestCounterPreservesTTL(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(skhron.WithClock[int64](clock))
+	counter := skhron.NewCounter(storage)
+
+	counter.IncrBy("window", 5, time.Minute)
+	clock.Advance(50 * time.Second)
+	if v, _ := counter.IncrBy("window", 5, time.Minute); v != 10 {
+		t.Errorf("IncrBy(window) = %d, want 10", v)
+	}
+
+	// the increment kept the expiration of the first one
+	clock.Advance(20 * time.Second)
+	storage.CleanUpN(0)
+	if storage.Exists("window") {
+		t.Errorf("counter expiration was extended by increment")
+	}
+
+	// an expired counter starts over
+	counter.IncrBy("stale", 1, time.Second)
+	clock.Advance(time.Minute)
+	if v, _ := counter.IncrBy("stale", 1, time.Second); v != 1 {
+		t.Errorf("IncrBy(stale) after expiry = %d, want 1", v)
+	}
+
+	// a new counter without TTL gets the default TTL of the storage
+	defaults := skhron.New(skhron.WithClock[int64](clock), skhron.WithDefaultTTL[int64](time.Minute))
+	skhron.NewCounter(defaults).Incr("hits")
+	if ttl, err := defaults.TTL("hits"); err != nil || ttl != time.Minute {
+		t.Errorf("TTL(hits) = %v, %v, want 1m, nil", ttl, err)
+	}
+}
+
+func TestCounterBounds(t *testing.T) {
+	counter := skhron.NewCounter(skhron.New[int64](), skhron.WithFloor(0), skhron.WithCeiling(10))
+
+	if v, _ := counter.IncrBy("key", 15, 0); v != 10 {
+		t.Errorf("IncrBy above ceiling = %d, want 10", v)
+	}
+	if v, _ := counter.DecrBy("key", 15, 0); v != 0 {
+		t.Errorf("DecrBy below floor = %d, want 0", v)
+	}
+
+	unbounded := skhron.NewCounter(skhron.New[int64]())
+	unbounded.IncrBy("key", math.MaxInt64-1, 0)
+	if v, err := unbounded.IncrBy("key", 2, 0); !errors.Is(err, skhron.ErrOverflow) || v != math.MaxInt64-1 {
+		t.Errorf("IncrBy with overflow = %d, %v, want %d, %v", v, err, int64(math.MaxInt64-1), skhron.ErrOverflow)
+	}
+	if _, err := unbounded.DecrBy("other", math.MinInt64, 0); !errors.Is(err, skhron.ErrOverflow) {
+		t.Errorf("DecrBy(MinInt64) = %v, want %v", err, skhron.ErrOverflow)
+	}
+}
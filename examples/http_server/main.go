@@ -10,28 +10,54 @@ import (
 	"time"
 
 	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/ratelimit"
 )
 
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	addr := flag.String("address", ":3567", "the address to listen on")
-	period := flag.Int("period", 10, "the period of time to run cleanup (in seconds)")
+	period := flag.Int("period", 10, "the period of time to create snapshots (in seconds)")
+	rate := flag.Float64("rate", 0, "the number of requests per second allowed for each client (0 disables rate limiting)")
+	burst := flag.Int("burst", 10, "the number of requests each client may send at once")
 
 	flag.Parse()
 
-	storage := skhron.New[[]byte]()
-	storage.LoadSnapshot()
+	log.Println("Opening storage with background cleanup and snapshots")
+	storage, err := skhron.Open(
+		skhron.WithValueCodec[[]byte](skhron.BytesCodec{}),
+		skhron.WithSnapshotInterval[[]byte](time.Duration(*period)*time.Second),
+	)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
 
-	server := newServer(*addr, storage)
+	leaseStorage, err := skhron.Open(
+		skhron.WithSnapshotName[skhron.Lease]("leases"),
+		skhron.WithSnapshotInterval[skhron.Lease](time.Duration(*period)*time.Second),
+	)
+	if err != nil {
+		log.Fatalf("Failed to open lease storage: %v", err)
+	}
+
+	var limiter ratelimit.Limiter
+	var buckets *skhron.Skhron[ratelimit.Bucket]
+	if *rate > 0 {
+		log.Println("Opening rate limiter storage")
+		buckets, err = skhron.Open(skhron.WithPersistence[ratelimit.Bucket](false))
+		if err != nil {
+			log.Fatalf("Failed to open rate limiter storage: %v", err)
+		}
+		if limiter, err = ratelimit.NewTokenBucket(buckets, *rate, *burst); err != nil {
+			log.Fatalf("Failed to create rate limiter: %v", err)
+		}
+	}
+
+	server := newServer(*addr, storage, skhron.NewLeases(leaseStorage), limiter)
 
 	log.Println("Running HTTP server in goroutine")
 	go server.Run(ctx)
 
-	log.Println("Running storage cleaning process in goroutine")
-	storage_shutdown := make(chan struct{})
-	go storage.PeriodicCleanup(ctx, time.Duration(*period)*time.Second, storage_shutdown)
-
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	signal.Notify(c, os.Interrupt, syscall.SIGINT)
@@ -42,5 +68,21 @@ func main() {
 
 	log.Println("Closing all processes...")
 	server.Shutdown(ctx)
-	<-storage_shutdown
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+
+	if err := storage.Close(shutdownCtx); err != nil {
+		log.Printf("Failed to close storage: %v", err)
+	}
+
+	if err := leaseStorage.Close(shutdownCtx); err != nil {
+		log.Printf("Failed to close lease storage: %v", err)
+	}
+
+	if buckets != nil {
+		if err := buckets.Close(shutdownCtx); err != nil {
+			log.Printf("Failed to close rate limiter storage: %v", err)
+		}
+	}
 }
@@ -3,24 +3,35 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/ratelimit"
 )
 
 type server struct {
 	strg *skhron.Skhron[[]byte]
 	addr string
 	serv *http.Server
+	ctx  context.Context   // server context, used to stop streaming responses
+	lses *skhron.Leases    // leases (distributed locks) of clients
+	lmtr ratelimit.Limiter // optional per-client rate limiter
 }
 
 type serverRes struct {
 	Status int
 	Body   []byte
+	ETag   string // optional ETag header value
 }
 
 type postReq struct {
@@ -33,13 +44,35 @@ type putReq struct {
 	TTL  int    `json:"ttl"` // TTL in seconds
 }
 
+type batchPutReq struct {
+	Key  string `json:"key"`
+	Data string `json:"data"`
+	TTL  int    `json:"ttl"` // TTL in seconds, zero means no TTL
+}
+
+type batchReq struct {
+	Put    []batchPutReq `json:"put"`
+	Get    []string      `json:"get"`
+	Delete []string      `json:"delete"`
+}
+
+type batchRes struct {
+	Put     []string          `json:"put"` // error message for each put item, empty on success
+	Get     map[string]string `json:"get"`
+	Deleted int               `json:"deleted"`
+}
+
 // New function creates a new server instance with a
 // specified address and initializes a new in-memory storage.
-func newServer(addr string, storage *skhron.Skhron[[]byte]) *server {
+// If limiter is not nil, requests of each client are limited by it.
+func newServer(addr string, storage *skhron.Skhron[[]byte], leases *skhron.Leases, limiter ratelimit.Limiter) *server {
 	return &server{
 		strg: storage,
 		addr: addr,
 		serv: nil,
+		ctx:  context.Background(),
+		lses: leases,
+		lmtr: limiter,
 	}
 }
 
@@ -48,11 +81,22 @@ func newServer(addr string, storage *skhron.Skhron[[]byte]) *server {
 func (s *server) Run(ctx context.Context) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.Serve)
+	mux.HandleFunc("/_batch", s.ServeBatch)
+	mux.HandleFunc("/_publish/", s.ServePublish)
+	mux.HandleFunc("/_subscribe", s.ServeSubscribe)
+	mux.HandleFunc("/_lease/", s.ServeLease)
+
+	s.ctx = ctx
+
+	var handler http.Handler = mux
+	if s.lmtr != nil {
+		handler = ratelimit.Middleware(s.lmtr, ratelimit.RemoteAddr)(mux)
+	}
 
 	log.Println("Creating server with provided context")
 	s.serv = &http.Server{
 		Addr:    s.addr,
-		Handler: mux,
+		Handler: handler,
 		BaseContext: func(l net.Listener) context.Context {
 			ctx := context.WithoutCancel(ctx)
 			return ctx
@@ -95,8 +139,255 @@ func (s *server) Serve(response http.ResponseWriter, request *http.Request) {
 		result = serverRes{Status: 405, Body: []byte("method not allowed")}
 	}
 
+	s.respond(response, request, result)
+}
+
+// ServeBatch function is a handler for POST /_batch requests.
+// It calls the batch handler and writes the status code
+// and response body to the ReponseWriter
+func (s *server) ServeBatch(response http.ResponseWriter, request *http.Request) {
+	var result serverRes
+
+	switch request.Method {
+	case http.MethodPost:
+		result = s.serveBatch(request)
+	default:
+		result = serverRes{Status: 405, Body: []byte("method not allowed")}
+	}
+
+	s.respond(response, request, result)
+}
+
+// ServePublish function is a handler for POST /_publish/:channel requests.
+// It publishes the request body to the channel.
+// Channel names containing line breaks are rejected with HTTP 400 status code (see validChannel).
+// If `retain` query parameter is present, the message is retained
+// for the specified number of seconds (zero means forever).
+// The number of receivers is returned with HTTP 200 status code.
+func (s *server) ServePublish(response http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		s.respond(response, request, serverRes{Status: 405, Body: []byte("method not allowed")})
+		return
+	}
+
+	channel := strings.TrimPrefix(request.URL.Path, "/_publish/")
+	if !validChannel(channel) {
+		s.respond(response, request, serverRes{Status: 400, Body: []byte("invalid channel")})
+		return
+	}
+
+	var msg []byte
+	if request.Body != nil {
+		defer request.Body.Close()
+
+		var err error
+		if msg, err = io.ReadAll(request.Body); err != nil {
+			s.respond(response, request, serverRes{Status: 422, Body: []byte(err.Error())})
+			return
+		}
+	}
+
+	var receivers int
+	if retain := request.URL.Query().Get("retain"); retain != "" {
+		seconds, err := strconv.Atoi(retain)
+		if err != nil {
+			s.respond(response, request, serverRes{Status: 400, Body: []byte("invalid retain parameter")})
+			return
+		}
+		receivers = s.strg.PublishRetained(channel, msg, time.Duration(seconds)*time.Second)
+	} else {
+		receivers = s.strg.Publish(channel, msg)
+	}
+
+	s.respond(response, request, serverRes{Status: 200, Body: []byte(strconv.Itoa(receivers))})
+}
+
+// ServeSubscribe function is a handler for GET /_subscribe requests.
+// It streams messages of the channels from `channel` query parameters
+// and of the channels matching glob patterns from `pattern` query parameters
+// as Server-Sent Events. The event name is the channel name.
+// Channels and patterns containing line breaks are rejected with HTTP 400 status code
+// and messages of such channels (published by other means) are skipped (see validChannel).
+// The stream is closed when the client disconnects or the server shuts down.
+func (s *server) ServeSubscribe(response http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		s.respond(response, request, serverRes{Status: 405, Body: []byte("method not allowed")})
+		return
+	}
+
+	flusher, ok := response.(http.Flusher)
+	if !ok {
+		s.respond(response, request, serverRes{Status: 500, Body: []byte("streaming is not supported")})
+		return
+	}
+
+	channels := request.URL.Query()["channel"]
+	patterns := make([]skhron.KeyFilter, 0)
+	for _, pattern := range request.URL.Query()["pattern"] {
+		if !validChannel(pattern) {
+			s.respond(response, request, serverRes{Status: 400, Body: []byte("invalid pattern")})
+			return
+		}
+		patterns = append(patterns, skhron.MatchGlob(pattern))
+	}
+	for _, channel := range channels {
+		if !validChannel(channel) {
+			s.respond(response, request, serverRes{Status: 400, Body: []byte("invalid channel")})
+			return
+		}
+	}
+	filter := func(channel string) bool {
+		if !validChannel(channel) {
+			return false
+		}
+		if slices.Contains(channels, channel) {
+			return true
+		}
+		for _, match := range patterns {
+			if match(channel) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(request.Context())
+	defer cancel()
+	stop := context.AfterFunc(s.ctx, cancel) // stop streaming on server shutdown
+	defer stop()
+
+	messages := s.strg.SubscribeMatch(ctx, filter)
+
+	log.Printf("%s %s - streaming\n", request.Method, request.URL.Path)
+
+	response.Header().Set("Content-Type", "text/event-stream")
+	response.Header().Set("Cache-Control", "no-cache")
+	response.WriteHeader(200)
+	flusher.Flush()
+
+	for msg := range messages {
+		fmt.Fprintf(response, "event: %s\n", msg.Channel)
+		for _, line := range sseLines.Split(string(msg.Payload), -1) {
+			fmt.Fprintf(response, "data: %s\n", line)
+		}
+		fmt.Fprint(response, "\n")
+		flusher.Flush()
+	}
+}
+
+// validChannel is a function which reports whether the channel name may be used in the `event` field
+// of Server-Sent Events: line breaks would end the field and let the name inject other fields.
+func validChannel(channel string) bool {
+	return !strings.ContainsAny(channel, "\r\n")
+}
+
+// sseLines matches line breaks of Server-Sent Events, so each line of a payload is sent in its own `data` field.
+var sseLines = regexp.MustCompile("\r\n|\r|\n")
+
+// ServeLease function is a handler for /_lease/:key requests.
+//   - POST acquires the lease for `owner` for `ttl` seconds, waiting up to `wait` seconds if it is held;
+//   - PUT renews the lease with `token` for `ttl` seconds;
+//   - DELETE releases the lease with `token`.
+//
+// The lease (nothing for DELETE) is returned as JSON with HTTP 200 status code.
+// If the lease is held by someone else or lost, HTTP 409 status code is returned.
+func (s *server) ServeLease(response http.ResponseWriter, request *http.Request) {
+	key := strings.TrimPrefix(request.URL.Path, "/_lease/")
+	query := request.URL.Query()
+
+	seconds := func(name string) (time.Duration, error) {
+		value := query.Get(name)
+		if value == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid %s parameter", name)
+		}
+		return time.Duration(n) * time.Second, nil
+	}
+
+	ttl, err := seconds("ttl")
+	if err == nil && ttl == 0 {
+		ttl = 30 * time.Second
+	}
+	wait, waitErr := seconds("wait")
+	token, tokenErr := strconv.ParseUint(query.Get("token"), 10, 64)
+	if err = errors.Join(err, waitErr); err != nil {
+		s.respond(response, request, serverRes{Status: 400, Body: []byte(err.Error())})
+		return
+	}
+
+	var lease skhron.Lease
+
+	switch request.Method {
+	case http.MethodPost:
+		owner := query.Get("owner")
+		if owner == "" {
+			s.respond(response, request, serverRes{Status: 400, Body: []byte("owner parameter is required")})
+			return
+		}
+
+		if wait > 0 {
+			ctx, cancel := context.WithTimeout(request.Context(), wait)
+			defer cancel()
+
+			lease, err = s.lses.Acquire(ctx, key, owner, ttl)
+			if errors.Is(err, context.DeadlineExceeded) {
+				lease, err = s.lses.AcquireLease(key, owner, ttl)
+			}
+		} else {
+			lease, err = s.lses.AcquireLease(key, owner, ttl)
+		}
+	case http.MethodPut:
+		if tokenErr != nil {
+			s.respond(response, request, serverRes{Status: 400, Body: []byte("invalid token parameter")})
+			return
+		}
+		lease, err = s.lses.Renew(skhron.Lease{Key: key, Token: token}, ttl)
+	case http.MethodDelete:
+		if tokenErr != nil {
+			s.respond(response, request, serverRes{Status: 400, Body: []byte("invalid token parameter")})
+			return
+		}
+		err = s.lses.Release(skhron.Lease{Key: key, Token: token})
+	default:
+		s.respond(response, request, serverRes{Status: 405, Body: []byte("method not allowed")})
+		return
+	}
+
+	status := 200
+	switch {
+	case errors.Is(err, skhron.ErrLeaseHeld), errors.Is(err, skhron.ErrLeaseLost):
+		status = 409
+	case err != nil:
+		s.respond(response, request, serverRes{Status: 500, Body: []byte(err.Error())})
+		return
+	}
+
+	if request.Method == http.MethodDelete {
+		s.respond(response, request, serverRes{Status: status})
+		return
+	}
+
+	body, err := json.Marshal(lease)
+	if err != nil {
+		s.respond(response, request, serverRes{Status: 500, Body: []byte(err.Error())})
+		return
+	}
+
+	s.respond(response, request, serverRes{Status: status, Body: body})
+}
+
+// respond function logs the request and writes the status code
+// and response body to the ReponseWriter
+func (s *server) respond(response http.ResponseWriter, request *http.Request, result serverRes) {
 	log.Printf("%s %s - %d\n", request.Method, request.URL.Path, result.Status)
 
+	if result.ETag != "" {
+		response.Header().Set("ETag", result.ETag)
+	}
+
 	response.WriteHeader(result.Status)
 	if _, err := response.Write(result.Body); err != nil {
 		log.Println("Failed to write response body!")
@@ -107,16 +398,17 @@ func (s *server) Serve(response http.ResponseWriter, request *http.Request) {
 // It removes the prefix "/" to obtain the `key` parameter.
 // It tries to fetch the value by the specified key from storage.
 // If the key is not present, HTTP 404 status code is returned.
-// On success, the value (bytes) is returned with HTTP 200 status code.
+// On success, the value (bytes) is returned with HTTP 200 status code
+// and the version of the value in ETag header.
 func (s *server) serveGet(r *http.Request) serverRes {
 	key := strings.TrimPrefix(r.URL.Path, "/")
 
-	value, err := s.strg.Get(key)
+	value, version, err := s.strg.GetWithVersion(key)
 	if err != nil {
 		return serverRes{Status: 404, Body: []byte("key does not exist")}
 	}
 
-	return serverRes{Status: 200, Body: value}
+	return serverRes{Status: 200, Body: value, ETag: formatETag(version)}
 }
 
 // servePost is a function that process POST /:key requets.
@@ -153,9 +445,26 @@ func (s *server) servePost(req *http.Request) serverRes {
 // serveDelete is a function that process DELETE /:key requets.
 // It removes the prefix "/" to obtain the `key` parameter.
 // It deletes the specified key from the storage and returns HTTP 204 status code.
+// If If-Match header is present and does not match the version of the value,
+// HTTP 412 status code is returned.
 func (s *server) serveDelete(r *http.Request) serverRes {
 	key := strings.TrimPrefix(r.URL.Path, "/")
 
+	if match := r.Header.Get("If-Match"); match != "" {
+		version, err := parseETag(match)
+		if err != nil {
+			return serverRes{Status: 400, Body: []byte(err.Error())}
+		}
+
+		if err := s.strg.DeleteIfVersion(key, version); errors.Is(err, skhron.ErrVersionMismatch) {
+			return serverRes{Status: 412, Body: []byte("version does not match")}
+		} else if err != nil {
+			return serverRes{Status: 500, Body: []byte(err.Error())}
+		}
+
+		return serverRes{Status: 204, Body: []byte{}}
+	}
+
 	if err := s.strg.Delete(key); err != nil {
 		return serverRes{Status: 500, Body: []byte(err.Error())}
 	}
@@ -169,7 +478,10 @@ func (s *server) serveDelete(r *http.Request) serverRes {
 // the `key` parameter in the storage, if key is already present.
 // If key is not already present, HTTP 404 status code is returned.
 // If the request body is missing, HTTP 422 status code is returned.
-// On success, HTTP 204 statuc code is returned.
+// If If-Match header is present, the value is put only if it matches the version
+// of the value, otherwise HTTP 412 status code is returned. In this case zero TTL
+// means the value does not expire.
+// On success, HTTP 204 statuc code is returned with the new version in ETag header.
 func (s *server) servePut(req *http.Request) serverRes {
 	key := strings.TrimPrefix(req.URL.Path, "/")
 
@@ -187,9 +499,98 @@ func (s *server) servePut(req *http.Request) serverRes {
 		return serverRes{Status: 422, Body: []byte(err.Error())}
 	}
 
-	if err := s.strg.PutTTL(key, []byte(value.Data), time.Duration(value.TTL)*time.Second); err != nil {
+	ttl := time.Duration(value.TTL) * time.Second
+
+	if match := req.Header.Get("If-Match"); match != "" {
+		expected, err := parseETag(match)
+		if err != nil {
+			return serverRes{Status: 400, Body: []byte(err.Error())}
+		}
+
+		version, err := s.strg.PutIfVersion(key, []byte(value.Data), ttl, expected)
+		if errors.Is(err, skhron.ErrVersionMismatch) {
+			return serverRes{Status: 412, Body: []byte("version does not match"), ETag: formatETag(version)}
+		} else if err != nil {
+			return serverRes{Status: 500, Body: []byte(err.Error())}
+		}
+
+		return serverRes{Status: 204, Body: []byte{}, ETag: formatETag(version)}
+	}
+
+	if err := s.strg.PutTTL(key, []byte(value.Data), ttl); err != nil {
 		return serverRes{Status: 500, Body: []byte(err.Error())}
 	}
 
+	if _, version, err := s.strg.GetWithVersion(key); err == nil {
+		return serverRes{Status: 204, Body: []byte{}, ETag: formatETag(version)}
+	}
+
 	return serverRes{Status: 204, Body: []byte{}}
 }
+
+// formatETag is a function that converts the version of a value into a strong ETag.
+func formatETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// parseETag is a function that converts If-Match header value into a version.
+func parseETag(etag string) (uint64, error) {
+	version, err := strconv.ParseUint(strings.Trim(etag, `"`), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ETag %s", etag)
+	}
+
+	return version, nil
+}
+
+// serveBatch is a function that process POST /_batch requests.
+// It reads "put", "get" and "delete" lists from the request body
+// and performs each of them with a single storage call.
+// Deletions are performed after puts and gets.
+// If the request body is missing, HTTP 422 status code is returned.
+// On success, the result of each operation is returned with HTTP 200 status code.
+func (s *server) serveBatch(req *http.Request) serverRes {
+	if req.Body == nil {
+		return serverRes{Status: 422, Body: []byte("missing request body")}
+	}
+	defer req.Body.Close()
+
+	var batch batchReq
+	if err := json.NewDecoder(req.Body).Decode(&batch); err != nil {
+		return serverRes{Status: 422, Body: []byte(err.Error())}
+	}
+
+	now := s.strg.Clock.Now()
+	entries := make([]skhron.Entry[[]byte], 0, len(batch.Put))
+	for _, item := range batch.Put {
+		entry := skhron.Entry[[]byte]{Key: item.Key, Value: []byte(item.Data)}
+		if item.TTL != 0 {
+			entry.Exp = now.Add(time.Duration(item.TTL) * time.Second)
+		}
+		entries = append(entries, entry)
+	}
+
+	result := batchRes{
+		Put: make([]string, len(entries)),
+		Get: make(map[string]string, len(batch.Get)),
+	}
+
+	for i, err := range s.strg.PutMany(entries) {
+		if err != nil {
+			result.Put[i] = err.Error()
+		}
+	}
+
+	for key, value := range s.strg.GetMany(batch.Get) {
+		result.Get[key] = string(value)
+	}
+
+	result.Deleted = s.strg.DeleteMany(batch.Delete)
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return serverRes{Status: 500, Body: []byte(err.Error())}
+	}
+
+	return serverRes{Status: 200, Body: body}
+}
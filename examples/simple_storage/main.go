@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -8,10 +9,15 @@ import (
 )
 
 func main() {
-	storage := skhron.New[string]() // init new storage
-	storage.LoadSnapshot()          // load snapshot from file (default: `./.skhron/snapshot.skh`)
-
-	storage.CleanUp() // manually run cleaup - remove expored keys
+	// open storage: load snapshot from file (default: `./.skhron/snapshot.skh`)
+	// and start removing expired keys in background
+	storage, err := skhron.Open[string]()
+	if err != nil {
+		fmt.Printf("Open failed: %v\n", err)
+		return
+	}
+	// stop background workers and save snapshot on exit
+	defer storage.Close(context.Background())
 
 	timestamp := time.Now().Format("2006_01_02 15:04:05")
 
@@ -38,8 +44,4 @@ func main() {
 	if err := storage.PutTTL("run-timestamp", timestamp, 1*time.Hour); err != nil {
 		fmt.Printf("Put failed: %v\n", err)
 	}
-
-	if err := storage.CreateSnapshot(); err != nil {
-		fmt.Printf("Create snapshot failed: %v\n", err)
-	}
 }
@@ -5,6 +5,14 @@ import "time"
 type expireItem struct {
 	Key string    `json:"key,omitempty"`
 	Exp time.Time `json:"exp,omitempty"`
+	// Field is a field of the hash stored under the key, which expires instead of the whole key
+	// (only for items with IsField set: an empty string is a valid field name).
+	Field   string `json:"field,omitempty"`
+	IsField bool   `json:"is_field"`
+	// Slide is a TTL the expiration is extended by on every read of the key (zero means it is not extended).
+	Slide time.Duration `json:"slide,omitempty"`
+
+	index int // position of the item in the queue, maintained by heap.Interface
 }
 
 type expireQueue []*expireItem
@@ -17,10 +25,16 @@ func newExpQueue() *expireQueue {
 // heap.Interface
 func (q expireQueue) Len() int           { return len(q) }
 func (q expireQueue) Less(i, j int) bool { return q[i].Exp.Before(q[j].Exp) }
-func (q expireQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q expireQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
 
 func (q *expireQueue) Push(x any) {
-	*q = append(*q, x.(*expireItem))
+	item := x.(*expireItem)
+	item.index = len(*q)
+	*q = append(*q, item)
 }
 
 func (q *expireQueue) Pop() any {
@@ -28,7 +42,16 @@ func (q *expireQueue) Pop() any {
 	n := len(qcopy)
 	item := qcopy[n-1]
 	qcopy[n-1] = nil // avoid memory leak
+	item.index = -1
 
 	*q = qcopy[0 : n-1]
 	return item
 }
+
+// peek returns the item which expires first, or nil if the queue is empty.
+func (q expireQueue) peek() *expireItem {
+	if len(q) == 0 {
+		return nil
+	}
+	return q[0]
+}
@@ -0,0 +1,373 @@
+package skhron
+
+import (
+	"container/heap"
+	"context"
+	"log"
+	"math/bits"
+	"time"
+)
+
+// schedule is a function which sets expiration time of the key.
+// If the key is already in the queue, the item is updated and the queue is fixed.
+// If the item becomes the first one to expire, the expiry scheduler is woken up.
+// The expiration is not extended by reads (see `expireAfter`).
+// The caller must hold the write lock.
+func (s *Skhron[V]) schedule(key string, exp time.Time) {
+	item, ok := s.ttlIndex[key]
+	if ok {
+		item.Exp = exp
+		item.Slide = 0
+		heap.Fix(s.TTLq, item.index)
+	} else {
+		item = &expireItem{Key: key, Exp: exp}
+		heap.Push(s.TTLq, item)
+		s.ttlIndex[key] = item
+	}
+
+	if s.TTLq.peek() == item {
+		s.wakeScheduler()
+	}
+}
+
+// expireAfter is a function which sets expiration time of the key to `now` + `ttl`.
+// If `sliding` is true, every read of the key extends its expiration by `ttl` (see `touch`).
+// The caller must hold the write lock.
+func (s *Skhron[V]) expireAfter(key string, now time.Time, ttl time.Duration, sliding bool) {
+	s.schedule(key, now.Add(ttl))
+	if sliding {
+		s.ttlIndex[key].Slide = ttl
+	}
+}
+
+// scheduleDefault is a function which sets expiration time of the key to `now` + `DefaultTTL`,
+// if `DefaultTTL` is set (skhron.WithDefaultTTL option).
+// The caller must hold the write lock.
+func (s *Skhron[V]) scheduleDefault(key string, now time.Time) {
+	if s.DefaultTTL > 0 {
+		s.expireTTL(key, now, s.DefaultTTL, s.TTLJitter)
+	}
+}
+
+// sliding is a function which reports whether reads of the key extend its expiration.
+// The caller must hold the lock (for reading at least).
+func (s *Skhron[V]) sliding(key string) bool {
+	item, ok := s.ttlIndex[key]
+	return ok && item.Slide > 0
+}
+
+// touch is a function which extends expiration of the sliding keys, which have not expired yet.
+// It is called after the keys are read, so readers only take the write lock for sliding keys.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) touch(keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Clock.Now()
+	for _, key := range keys {
+		if item, ok := s.ttlIndex[key]; ok && item.Slide > 0 && !item.Exp.Before(now) {
+			item.Exp = now.Add(item.Slide)
+			heap.Fix(s.TTLq, item.index)
+		}
+	}
+}
+
+// unschedule is a function which removes the key from the queue, so it does not expire.
+// The caller must hold the write lock.
+func (s *Skhron[V]) unschedule(key string) {
+	if item, ok := s.ttlIndex[key]; ok {
+		heap.Remove(s.TTLq, item.index)
+		delete(s.ttlIndex, key)
+	}
+}
+
+// Expire is a function which sets TTL of the key holding a value or a collection.
+// It returns false if the key is not present.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) Expire(key string, ttl time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Clock.Now()
+	if !s.present(key, now) {
+		return false
+	}
+
+	s.expireAfter(key, now, ttl, false)
+
+	return true
+}
+
+// Persist is a function which removes TTL of the key, so it does not expire.
+// It returns false if the key is not present or has no TTL.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) Persist(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.ttlIndex[key]; !ok || !s.present(key, s.Clock.Now()) {
+		return false
+	}
+
+	s.unschedule(key)
+
+	return true
+}
+
+// TTL is a function which returns the time left until the key expires (zero if it does not expire).
+// If the key is not present, ErrNoSuchKey is returned.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) TTL(key string) (time.Duration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.Clock.Now()
+	if !s.present(key, now) {
+		return 0, noSuchKey(key)
+	}
+
+	if item, ok := s.ttlIndex[key]; ok {
+		return item.Exp.Sub(now), nil
+	}
+
+	return 0, nil
+}
+
+// present is a function which reports whether the key holds a value or a collection, which has not expired by `now`.
+// The caller must hold the lock (for reading at least).
+func (s *Skhron[V]) present(key string, now time.Time) bool {
+	_, value := s.Data.Get2(key)
+	_, coll := s.colls[key]
+	return (value || coll) && !s.expired(key, now)
+}
+
+// scheduleMany is a function which sets expiration time of the keys of the entries.
+// Fixing the queue item by item takes O(k log n), so if the batch is large compared to the queue,
+// items are updated in place and the queue is rebuilt with heap.Init in O(n).
+// The caller must hold the write lock.
+func (s *Skhron[V]) scheduleMany(entries []Entry[V]) {
+	if len(entries) == 0 {
+		return
+	}
+
+	n := s.TTLq.Len() + len(entries)
+	if len(entries) < n/bits.Len(uint(n)) {
+		for _, e := range entries {
+			s.schedule(e.Key, e.Exp)
+		}
+		return
+	}
+
+	for _, e := range entries {
+		if item, ok := s.ttlIndex[e.Key]; ok {
+			item.Exp, item.Slide = e.Exp, 0
+		} else {
+			item = &expireItem{Key: e.Key, Exp: e.Exp}
+			s.TTLq.Push(item)
+			s.ttlIndex[e.Key] = item
+		}
+	}
+
+	heap.Init(s.TTLq)
+	s.wakeScheduler()
+}
+
+// expire is a function which removes items expired before `now`.
+// It removes at most `limit` items, if `limit` is positive.
+// It returns the number of removed items.
+// The caller must hold the write lock.
+func (s *Skhron[V]) expire(now time.Time, limit int) int {
+	deleted := 0
+
+	for item := s.TTLq.peek(); item != nil && item.Exp.Before(now); item = s.TTLq.peek() {
+		if limit > 0 && deleted >= limit {
+			break
+		}
+
+		heap.Pop(s.TTLq)
+
+		if item.IsField {
+			log.Printf("Field \"%s\" of key \"%s\" expired %f sec ago, deleting\n", item.Field, item.Key, now.Sub(item.Exp).Seconds())
+			s.expireField(item)
+			deleted++
+			continue
+		}
+
+		delete(s.ttlIndex, item.Key)
+
+		log.Printf("Item with key \"%s\" expired %f sec ago, deleting\n", item.Key, now.Sub(item.Exp).Seconds())
+		s.remove(item.Key, EventExpire)
+		deleted++
+	}
+
+	return deleted
+}
+
+// cleanUpBatch is a function which removes at most `limit` expired items
+// under a single lock acquisition. It returns the number of removed items.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) cleanUpBatch(limit int) int {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.expire(s.Clock.Now(), limit)
+}
+
+// CleanUpN is a function which removes at most `n` expired items (all of them, if `n` is not positive).
+// Items are removed in batches of `ExpiryBatch` items (skhron.WithExpiryBatch option)
+// and the lock is released between batches, so a mass expiry does not block readers for the entire sweep.
+// It returns the number of removed items.
+func (s *Skhron[V]) CleanUpN(n int) int {
+	deleted := 0
+
+	for n <= 0 || deleted < n {
+		batch := s.ExpiryBatch
+		if n > 0 && (batch <= 0 || batch > n-deleted) {
+			batch = n - deleted
+		}
+
+		removed := s.cleanUpBatch(batch)
+		deleted += removed
+
+		if batch <= 0 || removed < batch {
+			break
+		}
+	}
+
+	return deleted
+}
+
+// CleanUpFor is a function which removes expired items in batches (see `CleanUpN`)
+// until there are no expired items left or the `budget` is exhausted.
+// At least one batch is always processed.
+// It returns the number of removed items.
+func (s *Skhron[V]) CleanUpFor(budget time.Duration) int {
+	start := s.Clock.Now()
+	deleted := 0
+
+	for {
+		removed := s.cleanUpBatch(s.ExpiryBatch)
+		deleted += removed
+
+		if s.ExpiryBatch <= 0 || removed < s.ExpiryBatch || s.Clock.Now().Sub(start) >= budget {
+			break
+		}
+	}
+
+	return deleted
+}
+
+// CleanUpAdaptive is a function which runs a single adaptive cleanup cycle (similar to Redis active expiry).
+// It removes a batch of expired items and repeats while the expired share of the batch
+// is above `CleanupRepeatRatio` (skhron.WithCleanupRepeatRatio option)
+// and the cycle took less than `CleanupBudget` (skhron.WithCleanupBudget option).
+// It returns the number of removed items.
+func (s *Skhron[V]) CleanUpAdaptive() int {
+	if s.ExpiryBatch <= 0 {
+		return s.CleanUpFor(s.CleanupBudget)
+	}
+
+	start := s.Clock.Now()
+	deleted := 0
+
+	for {
+		removed := s.cleanUpBatch(s.ExpiryBatch)
+		deleted += removed
+
+		ratio := float64(removed) / float64(s.ExpiryBatch)
+		if ratio <= s.CleanupRepeatRatio || s.Clock.Now().Sub(start) >= s.CleanupBudget {
+			break
+		}
+	}
+
+	return deleted
+}
+
+// wakeScheduler is a function which notifies the expiry scheduler
+// that the first item of the queue has changed. It never blocks.
+func (s *Skhron[V]) wakeScheduler() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// expireDue is a function which removes at most `ExpiryBatch` expired items of the storage
+// and of each of its namespaces (recursively).
+// It returns the time until the first remaining item is due and false if there are no items.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) expireDue() (time.Duration, bool) {
+	s.mu.Lock()
+	now := s.Clock.Now()
+	s.expire(now, s.ExpiryBatch)
+	next := s.TTLq.peek()
+	var wait time.Duration
+	if next != nil {
+		wait = next.Exp.Sub(now)
+	}
+	s.mu.Unlock()
+	s.dispatch()
+
+	due := next != nil
+	s.eachNamespace(func(_ string, ns *Skhron[V]) {
+		if w, ok := ns.expireDue(); ok && (!due || w < wait) {
+			wait, due = w, true
+		}
+	})
+
+	return wait, due
+}
+
+// cleanUpAll is a function which removes all expired items of the storage
+// and of each of its namespaces (recursively).
+func (s *Skhron[V]) cleanUpAll() {
+	s.CleanUp()
+	s.eachNamespace(func(_ string, ns *Skhron[V]) { ns.cleanUpAll() })
+}
+
+// RunExpiryScheduler is a function which removes expired items close to their expiration time.
+// Instead of waking up every period, it sleeps until the first item of the queue is due
+// and is re-armed whenever an item with an earlier expiration is put into the storage.
+// Each wake up it removes at most `ExpiryBatch` items (skhron.WithExpiryBatch option)
+// and it never wakes up more often than `ExpiryGranularity` (skhron.WithExpiryGranularity option).
+// Namespaces of the storage share the scheduler (see `Namespace`).
+// It works until `ctx.Done()` signal is sent.
+func (s *Skhron[V]) RunExpiryScheduler(ctx context.Context) {
+	log.Printf("Starting expiry scheduler with granularity %s\n", s.ExpiryGranularity)
+
+	timer := s.Clock.NewTimer(time.Hour)
+	stopTimer(timer)
+
+	for {
+		wait, due := s.expireDue()
+
+		var fire <-chan time.Time
+		if due {
+			timer.Reset(max(wait, s.ExpiryGranularity))
+			fire = timer.C()
+		}
+
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			log.Println("Shutting down skhron expiry scheduler")
+			return
+		case <-s.wake:
+		case <-fire:
+		}
+
+		stopTimer(timer)
+	}
+}
+
+// stopTimer is a function which stops the timer and drains its channel,
+// so it can be safely reset.
+func stopTimer(t Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C():
+		default:
+		}
+	}
+}
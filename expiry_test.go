@@ -0,0 +1,302 @@
+package skhron_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/skhrontest"
+)
+
+// waitFor is a function which waits until `cond` is true or fails the test after a second.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition was not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestExpiryScheduler(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(skhron.WithClock[string](clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go storage.RunExpiryScheduler(ctx)
+
+	storage.PutTTL("late", "value", time.Hour)
+	clock.BlockUntil(1) // scheduler sleeps until "late" is due
+
+	// an earlier expiration re-arms the scheduler
+	storage.PutTTL("early", "value", time.Second)
+
+	clock.Advance(2 * time.Second)
+	waitFor(t, func() bool { return !storage.Exists("early") })
+
+	if !storage.Exists("late") {
+		t.Errorf("key \"late\" expired too early")
+	}
+
+	clock.Advance(time.Hour)
+	waitFor(t, func() bool { return !storage.Exists("late") })
+}
+
+func TestExpirySchedulerBatch(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(
+		skhron.WithClock[int](clock),
+		skhron.WithExpiryBatch[int](2),
+		skhron.WithExpiryGranularity[int](time.Second),
+	)
+
+	keys := []string{"a", "b", "c", "d", "e"}
+	for i, key := range keys {
+		storage.PutTTL(key, i, time.Minute)
+	}
+
+	left := func(n int) func() bool {
+		return func() bool {
+			count := 0
+			for _, key := range keys {
+				if storage.Exists(key) {
+					count++
+				}
+			}
+			return count == n
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go storage.RunExpiryScheduler(ctx)
+
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Minute)
+	waitFor(t, left(3))
+
+	// the rest is removed after the granularity passes
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	waitFor(t, left(1))
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	waitFor(t, left(0))
+}
+
+func TestCleanUpN(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(
+		skhron.WithClock[int](clock),
+		skhron.WithExpiryBatch[int](3),
+	)
+
+	for i := 0; i < 10; i++ {
+		storage.PutTTL(string(rune('a'+i)), i, time.Duration(i+1)*time.Second)
+	}
+	storage.PutTTL("late", 0, time.Hour)
+
+	clock.Advance(time.Minute)
+
+	tests := []struct {
+		name    string
+		n       int
+		deleted int
+	}{
+		{name: "Less than batch", n: 2, deleted: 2},
+		{name: "More than batch", n: 5, deleted: 5},
+		{name: "All expired", n: 0, deleted: 3},
+		{name: "Nothing left", n: 0, deleted: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if deleted := storage.CleanUpN(tt.n); deleted != tt.deleted {
+				t.Errorf("CleanUpN(%d) = %d, want %d", tt.n, deleted, tt.deleted)
+			}
+		})
+	}
+
+	if !storage.Exists("late") {
+		t.Errorf("key \"late\" expired too early")
+	}
+}
+
+func TestCleanUpAdaptive(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(
+		skhron.WithClock[int](clock),
+		skhron.WithExpiryBatch[int](4),
+		skhron.WithCleanupRepeatRatio[int](0.5),
+	)
+
+	for i := 0; i < 10; i++ {
+		storage.PutTTL(string(rune('a'+i)), i, time.Second)
+	}
+	storage.PutTTL("late", 0, time.Hour)
+
+	clock.Advance(time.Minute)
+
+	// batches of 4, 4 and 2 keys: the last batch is not above the ratio
+	if deleted := storage.CleanUpAdaptive(); deleted != 10 {
+		t.Errorf("CleanUpAdaptive() = %d, want 10", deleted)
+	}
+
+	if !storage.Exists("late") {
+		t.Errorf("key \"late\" expired too early")
+	}
+}
+
+func TestSlidingExpiration(t *testing.T) {
+	dir := t.TempDir()
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts := []skhron.StorageOpt[int]{
+		skhron.WithClock[int](clock),
+		skhron.WithSnapshotDir[int](dir),
+		skhron.WithTempSnapshotDir[int](dir),
+	}
+	storage := skhron.New(opts...)
+
+	storage.PutSliding("session", 1, time.Minute)
+	storage.PutTTL("fixed", 2, time.Minute)
+
+	// every read extends the sliding key, but not the fixed one
+	for i := 0; i < 3; i++ {
+		clock.Advance(40 * time.Second)
+		storage.Get("session")
+		storage.Get("fixed")
+		storage.CleanUpN(0)
+	}
+
+	if !storage.Exists("session") {
+		t.Errorf("sliding key expired while it was read")
+	}
+	if storage.Exists("fixed") {
+		t.Errorf("fixed key was extended by reads")
+	}
+
+	// sliding TTL survives snapshots
+	if err := storage.CreateSnapshot(); err != nil {
+		t.Fatalf("create snapshot failed: %v", err)
+	}
+	loaded := skhron.New(opts...)
+	if err := loaded.LoadSnapshot(); err != nil {
+		t.Fatalf("load snapshot failed: %v", err)
+	}
+
+	clock.Advance(40 * time.Second)
+	loaded.GetMany([]string{"session"})
+	clock.Advance(40 * time.Second)
+	loaded.CleanUpN(0)
+	if !loaded.Exists("session") {
+		t.Errorf("sliding key expired after snapshot while it was read")
+	}
+
+	clock.Advance(time.Minute + time.Second)
+	loaded.CleanUpN(0)
+	if loaded.Exists("session") {
+		t.Errorf("idle sliding key did not expire")
+	}
+}
+
+func TestDefaultSlidingTTL(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(
+		skhron.WithClock[int](clock),
+		skhron.WithDefaultTTL[int](time.Minute),
+		skhron.WithSlidingExpiration[int](),
+	)
+
+	storage.Put("read", 1)
+	storage.Put("idle", 2)
+
+	clock.Advance(40 * time.Second)
+	storage.GetWithVersion("read")
+	clock.Advance(40 * time.Second)
+	storage.CleanUpN(0)
+
+	if !storage.Exists("read") {
+		t.Errorf("key with default sliding TTL expired while it was read")
+	}
+	if storage.Exists("idle") {
+		t.Errorf("idle key with default TTL did not expire")
+	}
+}
+
+func TestDeleteClearsTTL(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(skhron.WithClock[int](clock))
+
+	storage.PutTTL("fixed", 1, time.Minute)
+	storage.PutSliding("sliding", 2, time.Minute)
+	storage.Delete("fixed")
+	storage.Delete("sliding")
+	storage.Put("fixed", 3)
+	storage.Put("sliding", 4)
+
+	for _, key := range []string{"fixed", "sliding"} {
+		if ttl, err := storage.TTL(key); err != nil || ttl != 0 {
+			t.Errorf("TTL(%s) after delete and put = %v, %v, want 0, nil", key, ttl, err)
+		}
+	}
+
+	storage.Get("sliding")
+	clock.Advance(2 * time.Minute)
+	if n := storage.CleanUpN(0); n != 0 || storage.Len() != 2 {
+		t.Errorf("CleanUpN() = %d, Len() = %d, want 0, 2", n, storage.Len())
+	}
+}
+
+func TestTTLJitter(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	start := clock.Now()
+
+	expirations := func(seed uint64) []time.Time {
+		storage := skhron.New(
+			skhron.WithClock[int](clock),
+			skhron.WithTTLJitter[int](0.2),
+			skhron.WithJitterSeed[int](seed),
+		)
+		for i := 0; i < 100; i++ {
+			storage.PutTTL(fmt.Sprintf("key%03d", i), i, 100*time.Second)
+		}
+		storage.PutTTL("exact", 0, 100*time.Second, skhron.WithJitter(0))
+
+		entries, _ := storage.FindGlob("*", skhron.FindOpts{})
+		slices.SortFunc(entries, func(a, b skhron.Entry[int]) int { return strings.Compare(a.Key, b.Key) })
+
+		exps := make([]time.Time, 0, len(entries))
+		for _, e := range entries {
+			exps = append(exps, e.Exp)
+		}
+		return exps
+	}
+
+	exps := expirations(42)
+	if !exps[0].Equal(start.Add(100 * time.Second)) {
+		t.Errorf("key put with WithJitter(0) expires at %v, want %v", exps[0], start.Add(100*time.Second))
+	}
+
+	distinct := make(map[time.Time]struct{})
+	for _, exp := range exps[1:] {
+		if ttl := exp.Sub(start); ttl < 80*time.Second || ttl > 120*time.Second {
+			t.Errorf("jittered ttl %v is out of bounds", ttl)
+		}
+		distinct[exp] = struct{}{}
+	}
+	if len(distinct) < 90 {
+		t.Errorf("expected expirations to be spread, got %d distinct values", len(distinct))
+	}
+
+	if !slices.EqualFunc(exps, expirations(42), time.Time.Equal) {
+		t.Errorf("expirations differ for the same seed")
+	}
+}
@@ -0,0 +1,191 @@
+package skhron
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// FindOpts is a set of options of Skhron.FindRegex and Skhron.FindGlob.
+type FindOpts struct {
+	// Maximum number of entries to return, zero means no limit
+	Limit int
+	// Cursor returned by the previous call, empty cursor means the first page
+	Cursor string
+}
+
+// FindRegex is a function which fetches entries (keys, values and expiration times)
+// under keys, which match the mask regex, in ascending order of keys.
+// It returns at most `opts.Limit` entries and the cursor of the next page,
+// which is empty when there are no more matching keys.
+// Expired items are skipped.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) FindRegex(mask *regexp.Regexp, opts FindOpts) ([]Entry[V], string) {
+	return s.find(mask.MatchString, "", opts)
+}
+
+// FindGlob is a function which fetches entries under keys, which match the glob pattern.
+// Supported syntax: `*` matches any sequence of characters, `?` matches a single character,
+// `[abc]`, `[a-z]` and `[!a]` match character classes, `\` escapes the next character.
+// Unlike regex, the pattern has a literal prefix (e.g. "user:" in "user:*:session"),
+// so with skhron.WithOrderedIndex option only keys with this prefix are visited.
+// It has the same pagination semantics as `FindRegex`.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) FindGlob(pattern string, opts FindOpts) ([]Entry[V], string) {
+	return s.find(func(key string) bool {
+		return matchGlob(pattern, key)
+	}, globPrefix(pattern), opts)
+}
+
+// find is a function which fetches entries with keys starting with `prefix` that satisfy `match`
+// (all keys, if `match` is nil) in ascending order of keys.
+// It uses the ordered index if it is present, otherwise it sorts matching keys.
+func (s *Skhron[V]) find(match func(key string) bool, prefix string, opts FindOpts) ([]Entry[V], string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start := max(opts.Cursor, prefix)
+	end := prefixEnd(prefix)
+
+	entries := make([]Entry[V], 0)
+	next := ""
+	now := s.Clock.Now()
+
+	// visit adds the key to the result and reports whether more keys are needed
+	visit := func(key string) bool {
+		if match != nil && !match(key) {
+			return true
+		}
+		value, ok := s.Data.Get2(key)
+		if !ok || s.expired(key, now) {
+			return true
+		}
+		if opts.Limit > 0 && len(entries) == opts.Limit {
+			next = entries[len(entries)-1].Key + "\x00" // the smallest key after the last one
+			return false
+		}
+		entries = append(entries, s.entry(key, value))
+		return true
+	}
+
+	if s.ordered != nil {
+		for node := s.ordered.findPrev(start, nil); node != nil; node = node.next[0] {
+			if end != "" && node.key >= end || !visit(node.key) {
+				break
+			}
+		}
+	} else {
+		for _, key := range s.sortedKeys(start, end) {
+			if !visit(key) {
+				break
+			}
+		}
+	}
+
+	return entries, next
+}
+
+// globPrefix is a function which returns the literal prefix of the glob pattern.
+func globPrefix(pattern string) string {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?', '[', '\\':
+			return pattern[:i]
+		}
+	}
+	return pattern
+}
+
+// matchGlob is a function which checks whether the string matches the glob pattern.
+func matchGlob(pattern, s string) bool {
+	px, sx := 0, 0
+	starPx, starSx := -1, 0 // position after the last star, used for backtracking
+
+	for px < len(pattern) || sx < len(s) {
+		if px < len(pattern) {
+			switch pattern[px] {
+			case '*':
+				starPx, starSx = px, sx
+				px++
+				continue
+			case '?':
+				if sx < len(s) {
+					_, size := utf8.DecodeRuneInString(s[sx:])
+					px, sx = px+1, sx+size
+					continue
+				}
+			case '[':
+				if sx < len(s) {
+					r, size := utf8.DecodeRuneInString(s[sx:])
+					matched, width := matchClass(pattern[px:], r)
+					if width == 0 && s[sx] == '[' { // unclosed class is a literal
+						px, sx = px+1, sx+1
+						continue
+					}
+					if width > 0 && matched {
+						px, sx = px+width, sx+size
+						continue
+					}
+				}
+			case '\\':
+				if px+1 < len(pattern) && sx < len(s) && pattern[px+1] == s[sx] {
+					px, sx = px+2, sx+1
+					continue
+				}
+			default:
+				if sx < len(s) && pattern[px] == s[sx] {
+					px, sx = px+1, sx+1
+					continue
+				}
+			}
+		}
+
+		// mismatch: let the last star match one more character
+		if starPx >= 0 && starSx < len(s) {
+			_, size := utf8.DecodeRuneInString(s[starSx:])
+			starSx += size
+			px, sx = starPx+1, starSx
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// matchClass is a function which matches the rune against the character class
+// at the beginning of the pattern (e.g. "[a-z]").
+// It returns the width of the class in the pattern, which is zero if the class is not closed.
+func matchClass(pattern string, r rune) (bool, int) {
+	i := 1
+	negate := i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^')
+	if negate {
+		i++
+	}
+
+	matched := false
+	for first := true; i < len(pattern); first = false {
+		if pattern[i] == ']' && !first {
+			return matched != negate, i + 1
+		}
+
+		lo, size := utf8.DecodeRuneInString(pattern[i:])
+		if lo == '\\' && i+size < len(pattern) {
+			i += size
+			lo, size = utf8.DecodeRuneInString(pattern[i:])
+		}
+		i += size
+
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi, size = utf8.DecodeRuneInString(pattern[i+1:])
+			i += 1 + size
+		}
+
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+
+	return false, 0
+}
@@ -0,0 +1,94 @@
+package skhron
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{pattern: "user:*:session", key: "user:42:session", want: true},
+		{pattern: "user:*:session", key: "user::session", want: true},
+		{pattern: "user:*:session", key: "user:42:profile", want: false},
+		{pattern: "user:?", key: "user:1", want: true},
+		{pattern: "user:?", key: "user:ё", want: true},
+		{pattern: "user:?", key: "user:12", want: false},
+		{pattern: "*", key: "", want: true},
+		{pattern: "a*b*c", key: "aXbYbZc", want: true},
+		{pattern: "a*b*c", key: "aXbYbZ", want: false},
+		{pattern: "key[0-9]", key: "key7", want: true},
+		{pattern: "key[!0-9]", key: "key7", want: false},
+		{pattern: "key[abc]", key: "keyb", want: true},
+		{pattern: "key[", key: "key[", want: true},
+		{pattern: `key\*`, key: "key*", want: true},
+		{pattern: `key\*`, key: "keys", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	configs := map[string][]StorageOpt[string]{
+		"without index": nil,
+		"with index":    {WithOrderedIndex[string]()},
+	}
+
+	for name, opts := range configs {
+		t.Run(name, func(t *testing.T) {
+			s := New(opts...)
+
+			s.Put("user:1:session", "s1")
+			s.Put("user:2:session", "s2")
+			s.Put("user:2:profile", "p2")
+			s.PutTTL("user:3:session", "s3", time.Hour)
+			s.PutTTL("user:4:session", "s4", -time.Second) // expired
+			s.Put("group:1:session", "g1")
+
+			keys := func(entries []Entry[string]) []string {
+				result := []string{}
+				for _, e := range entries {
+					result = append(result, e.Key)
+				}
+				return result
+			}
+
+			entries, next := s.FindGlob("user:*:session", FindOpts{})
+			want := []string{"user:1:session", "user:2:session", "user:3:session"}
+			if !reflect.DeepEqual(keys(entries), want) || next != "" {
+				t.Errorf("FindGlob() = %v, %q, want %v", keys(entries), next, want)
+			}
+			if entries[2].Value != "s3" || entries[2].Exp.IsZero() || !entries[0].Exp.IsZero() {
+				t.Errorf("FindGlob() returned invalid entries: %v", entries)
+			}
+
+			mask := regexp.MustCompile(`:session$`)
+			var found []string
+			cursor := ""
+			for pages := 0; ; pages++ {
+				if pages > 4 {
+					t.Fatalf("too many pages")
+				}
+				entries, next := s.FindRegex(mask, FindOpts{Limit: 2, Cursor: cursor})
+				found = append(found, keys(entries)...)
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+			want = []string{"group:1:session", "user:1:session", "user:2:session", "user:3:session"}
+			if !reflect.DeepEqual(found, want) {
+				t.Errorf("FindRegex() pages = %v, want %v", found, want)
+			}
+		})
+	}
+}
@@ -1,5 +1,5 @@
 module github.com/dartt0n/skhron
 
-go 1.21.0
+go 1.23
 
 require github.com/go-auxiliaries/shrinking-map v0.3.0
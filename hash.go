@@ -0,0 +1,130 @@
+package skhron
+
+import "maps"
+
+// HSet is a function which sets the field of the hash stored under the key to the value.
+// A missing hash is created.
+// It returns true if the field is new. An existing field keeps its expiration (see `HExpire`).
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) HSet(key, field string, value V) (bool, error) {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.writeCollection(key, CollectionHash, true)
+	if err != nil {
+		return false, err
+	}
+
+	exists := s.hasField(c, key, field, s.Clock.Now())
+	if !exists {
+		// an expired field, which has not been removed by cleanup yet, is replaced with a new one
+		s.unscheduleField(key, field)
+	}
+	c.hash[field] = value
+
+	s.changed(key, c)
+
+	return !exists, nil
+}
+
+// HGet is a function which returns the value of the field of the hash stored under the key.
+// If the hash or the field is missing (or the field has expired, see `HExpire`), ErrNoSuchKey is returned,
+// if the key holds another type, ErrWrongType is returned.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) HGet(key, field string) (V, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.readCollection(key, CollectionHash)
+	if err != nil {
+		return *new(V), err
+	}
+	if c == nil {
+		return *new(V), noSuchKey(key)
+	}
+
+	if !s.hasField(c, key, field, s.Clock.Now()) {
+		return *new(V), noSuchField(key, field)
+	}
+
+	return c.hash[field], nil
+}
+
+// HDel is a function which removes fields from the hash stored under the key.
+// The hash is removed when it becomes empty.
+// It returns the number of removed fields.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) HDel(key string, fields ...string) (int, error) {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.writeCollection(key, CollectionHash, false)
+	if err != nil || c == nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, field := range fields {
+		if _, ok := c.hash[field]; ok {
+			delete(c.hash, field)
+			s.unscheduleField(key, field)
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		s.changed(key, c)
+	}
+
+	return removed, nil
+}
+
+// HGetAll is a function which returns a copy of the hash stored under the key (empty if it is missing)
+// without expired fields.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) HGetAll(key string) (map[string]V, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.readCollection(key, CollectionHash)
+	if err != nil || c == nil {
+		return map[string]V{}, err
+	}
+
+	hash := maps.Clone(c.hash)
+	now := s.Clock.Now()
+	for field := range s.fieldIndex[key] {
+		if s.fieldExpired(key, field, now) {
+			delete(hash, field)
+		}
+	}
+
+	return hash, nil
+}
+
+// HLen is a function which returns the number of fields of the hash stored under the key (without expired fields).
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) HLen(key string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.readCollection(key, CollectionHash)
+	if err != nil || c == nil {
+		return 0, err
+	}
+
+	n, now := len(c.hash), s.Clock.Now()
+	for field := range s.fieldIndex[key] {
+		if s.fieldExpired(key, field, now) {
+			n--
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,176 @@
+package skhron
+
+import (
+	"container/heap"
+	"fmt"
+	"time"
+)
+
+// HExpire is a function which sets TTL of the field of the hash stored under the key.
+// The field is removed after `ttl` independently of other fields and the key itself
+// (the hash is removed when its last field expires). Setting the field keeps its expiration.
+// It returns false if the hash or the field is missing.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) HExpire(key, field string, ttl time.Duration) (bool, error) {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Clock.Now()
+
+	c, err := s.writeCollection(key, CollectionHash, false)
+	if err != nil || c == nil || !s.hasField(c, key, field, now) {
+		return false, err
+	}
+
+	s.scheduleField(key, field, now.Add(ttl))
+
+	return true, nil
+}
+
+// HPersist is a function which removes TTL of the field of the hash stored under the key.
+// It returns false if the hash or the field is missing, or the field has no TTL.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) HPersist(key, field string) (bool, error) {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.writeCollection(key, CollectionHash, false)
+	if err != nil || c == nil || !s.hasField(c, key, field, s.Clock.Now()) {
+		return false, err
+	}
+
+	if _, ok := s.fieldIndex[key][field]; !ok {
+		return false, nil
+	}
+
+	s.unscheduleField(key, field)
+
+	return true, nil
+}
+
+// HTTL is a function which returns the time left until the field of the hash stored under the key expires
+// (zero if it does not expire). Expiration of the key itself is not taken into account (see `TTL`).
+// If the hash or the field is missing, ErrNoSuchKey is returned,
+// if the key holds another type, ErrWrongType is returned.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) HTTL(key, field string) (time.Duration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.Clock.Now()
+
+	c, err := s.readCollection(key, CollectionHash)
+	if err != nil {
+		return 0, err
+	}
+	if c == nil || !s.hasField(c, key, field, now) {
+		return 0, noSuchField(key, field)
+	}
+
+	if item, ok := s.fieldIndex[key][field]; ok {
+		return item.Exp.Sub(now), nil
+	}
+
+	return 0, nil
+}
+
+// hasField is a function which reports whether the hash has the field, which has not expired by `now`.
+// The caller must hold the lock (for reading at least).
+func (s *Skhron[V]) hasField(c *collection[V], key, field string, now time.Time) bool {
+	_, ok := c.hash[field]
+	return ok && !s.fieldExpired(key, field, now)
+}
+
+// fieldExpired is a function which checks whether the field of the hash has expired by `now`,
+// but has not been removed by cleanup yet.
+// The caller must hold the lock (for reading at least).
+func (s *Skhron[V]) fieldExpired(key, field string, now time.Time) bool {
+	item, ok := s.fieldIndex[key][field]
+	return ok && item.Exp.Before(now)
+}
+
+// scheduleField is a function which sets expiration time of the field of the hash (see `schedule`).
+// The caller must hold the write lock.
+func (s *Skhron[V]) scheduleField(key, field string, exp time.Time) {
+	item, ok := s.fieldIndex[key][field]
+	if ok {
+		item.Exp = exp
+		heap.Fix(s.TTLq, item.index)
+	} else {
+		item = &expireItem{Key: key, Field: field, IsField: true, Exp: exp}
+		heap.Push(s.TTLq, item)
+		s.indexField(item)
+	}
+
+	if s.TTLq.peek() == item {
+		s.wakeScheduler()
+	}
+}
+
+// indexField is a function which adds the item of the field to the field index.
+// The caller must hold the write lock.
+func (s *Skhron[V]) indexField(item *expireItem) {
+	fields, ok := s.fieldIndex[item.Key]
+	if !ok {
+		fields = make(map[string]*expireItem)
+		s.fieldIndex[item.Key] = fields
+	}
+	fields[item.Field] = item
+}
+
+// unscheduleField is a function which removes the field of the hash from the queue, so it does not expire.
+// The caller must hold the write lock.
+func (s *Skhron[V]) unscheduleField(key, field string) {
+	fields := s.fieldIndex[key]
+	if item, ok := fields[field]; ok {
+		heap.Remove(s.TTLq, item.index)
+		delete(fields, field)
+		if len(fields) == 0 {
+			delete(s.fieldIndex, key)
+		}
+	}
+}
+
+// unscheduleFields is a function which removes all fields of the hash from the queue.
+// The caller must hold the write lock.
+func (s *Skhron[V]) unscheduleFields(key string) {
+	for _, item := range s.fieldIndex[key] {
+		heap.Remove(s.TTLq, item.index)
+	}
+	delete(s.fieldIndex, key)
+}
+
+// expireField is a function which removes the expired field of the hash, the item of which
+// has been popped from the queue. Watchers are notified with EventPut, or with EventExpire
+// if the hash is removed with its last field.
+// The caller must hold the write lock.
+func (s *Skhron[V]) expireField(item *expireItem) {
+	if fields := s.fieldIndex[item.Key]; fields[item.Field] == item {
+		delete(fields, item.Field)
+		if len(fields) == 0 {
+			delete(s.fieldIndex, item.Key)
+		}
+	}
+
+	c, ok := s.colls[item.Key]
+	if !ok || c.kind != CollectionHash {
+		return
+	}
+	delete(c.hash, item.Field)
+
+	if c.len() == 0 {
+		s.remove(item.Key, EventExpire)
+		return
+	}
+
+	s.bump(item.Key)
+}
+
+// noSuchField is a function which creates an error for a missing field of a hash.
+func noSuchField(key, field string) error {
+	return fmt.Errorf("%w: %s (field %s)", ErrNoSuchKey, key, field)
+}
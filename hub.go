@@ -0,0 +1,328 @@
+package skhron
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy defines what happens when a subscriber buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDrop drops messages which do not fit into the subscriber buffer.
+	OverflowDrop OverflowPolicy = iota
+	// OverflowQueue keeps messages which do not fit into the subscriber buffer in a queue of the subscriber
+	// and delivers them in order as the subscriber reads. Writers are never blocked, so the subscriber
+	// may write to the storage. The queue is bounded (skhron.WithQueueLimit option):
+	// when it is full, the subscriber is disconnected, so no message is lost silently.
+	OverflowQueue
+	// OverflowDisconnect closes the subscriber channel when its buffer is full.
+	OverflowDisconnect
+)
+
+// SubscribeOpt is an option of a subscription (see Skhron.Watch and Skhron.Subscribe).
+type SubscribeOpt func(c *subscribeConfig)
+
+type subscribeConfig struct {
+	buffer     int
+	overflow   OverflowPolicy
+	queueLimit int
+}
+
+// WithBuffer sets the size of the subscriber channel buffer (default 64).
+func WithBuffer(size int) SubscribeOpt {
+	return func(c *subscribeConfig) {
+		c.buffer = size
+	}
+}
+
+// WithOverflow sets the policy applied when the subscriber buffer is full (default OverflowDrop).
+func WithOverflow(policy OverflowPolicy) SubscribeOpt {
+	return func(c *subscribeConfig) {
+		c.overflow = policy
+	}
+}
+
+// WithQueueLimit sets the maximum number of messages queued in addition to the buffer
+// of OverflowQueue subscriber (default 1024).
+func WithQueueLimit(limit int) SubscribeOpt {
+	return func(c *subscribeConfig) {
+		c.queueLimit = limit
+	}
+}
+
+// hub delivers messages to subscribers.
+// Messages are queued while the storage lock is held (preserving the order of writes)
+// and delivered by `dispatch` after the lock is released, so slow subscribers never block the storage.
+type hub[T any] struct {
+	active atomic.Int32 // number of subscribers, checked before queueing
+
+	qmu   sync.Mutex // protects queue, subs and seq
+	queue []queued[T]
+	subs  []*subscriber[T]
+	seq   uint64 // sequence number of the last queued message
+
+	dmu sync.Mutex // serializes delivery, so messages are delivered in queue order
+}
+
+// queued is a message with its sequence number.
+type queued[T any] struct {
+	seq uint64
+	msg T
+}
+
+type subscriber[T any] struct {
+	ctx      context.Context
+	from     uint64 // sequence number of the first message the subscriber receives
+	match    func(msg T) bool
+	ch       chan T
+	overflow OverflowPolicy
+	closed   bool          // protected by hub.dmu
+	done     chan struct{} // closed by `unsubscribe`, so goroutines of the subscriber exit
+
+	// backlog of OverflowQueue subscribers: messages which did not fit into the buffer,
+	// sent to the channel by the `pump` goroutine
+	bmu        sync.Mutex
+	backlog    []T
+	queueLimit int           // maximum length of the backlog
+	signal     chan struct{} // signaled when the backlog grows
+	stopped    chan struct{} // closed when `pump` exits, so the channel may be closed
+}
+
+// subscribe is a function which registers a subscriber receiving messages which satisfy `match`.
+// Messages queued before the subscription are not delivered to the subscriber.
+// The subscriber channel is closed when `ctx` is done (or on overflow with OverflowDisconnect
+// and OverflowQueue policies).
+func (h *hub[T]) subscribe(ctx context.Context, match func(msg T) bool, opts []SubscribeOpt) *subscriber[T] {
+	sub := newSubscriber(ctx, match, opts)
+	h.add(sub)
+
+	return sub
+}
+
+func newSubscriber[T any](ctx context.Context, match func(msg T) bool, opts []SubscribeOpt) *subscriber[T] {
+	cfg := subscribeConfig{buffer: 64, overflow: OverflowDrop, queueLimit: 1024}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return &subscriber[T]{
+		ctx:        ctx,
+		match:      match,
+		ch:         make(chan T, cfg.buffer),
+		overflow:   cfg.overflow,
+		done:       make(chan struct{}),
+		queueLimit: cfg.queueLimit,
+		signal:     make(chan struct{}, 1),
+		stopped:    make(chan struct{}),
+	}
+}
+
+// add is a function which registers the subscriber.
+// It starts a goroutine which removes the subscriber when its context is done, unless it is removed earlier
+// (and a goroutine sending the backlog of OverflowQueue subscriber).
+func (h *hub[T]) add(sub *subscriber[T]) {
+	h.qmu.Lock()
+	sub.from = h.seq + 1
+	h.subs = append(h.subs, sub)
+	h.active.Add(1)
+	h.qmu.Unlock()
+
+	if sub.overflow == OverflowQueue {
+		go sub.pump()
+	} else {
+		close(sub.stopped)
+	}
+
+	go func() {
+		select {
+		case <-sub.ctx.Done():
+		case <-sub.done:
+			return
+		}
+
+		h.dmu.Lock()
+		defer h.dmu.Unlock()
+
+		h.unsubscribe(sub)
+	}()
+}
+
+// unsubscribe is a function which removes the subscriber and closes its channel.
+// The caller must hold dmu.
+func (h *hub[T]) unsubscribe(sub *subscriber[T]) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.done)
+	<-sub.stopped
+	close(sub.ch)
+
+	h.qmu.Lock()
+	defer h.qmu.Unlock()
+
+	for i, s := range h.subs {
+		if s == sub {
+			h.subs = append(h.subs[:i:i], h.subs[i+1:]...)
+			h.active.Add(-1)
+			break
+		}
+	}
+}
+
+// publish is a function which queues the message if there are any subscribers.
+// It is cheap and never blocks on subscribers, so it can be called while the storage lock is held.
+func (h *hub[T]) publish(msg T) {
+	if h.active.Load() == 0 {
+		return
+	}
+
+	h.qmu.Lock()
+	h.seq++
+	h.queue = append(h.queue, queued[T]{seq: h.seq, msg: msg})
+	h.qmu.Unlock()
+}
+
+// receivers is a function which returns the number of subscribers the message would be delivered to.
+func (h *hub[T]) receivers(msg T) int {
+	if h.active.Load() == 0 {
+		return 0
+	}
+
+	h.qmu.Lock()
+	defer h.qmu.Unlock()
+
+	count := 0
+	for _, sub := range h.subs {
+		if sub.match(msg) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// dispatch is a function which delivers queued messages to subscribers.
+// It must be called without holding the storage lock.
+func (h *hub[T]) dispatch() {
+	h.qmu.Lock()
+	empty := len(h.queue) == 0
+	h.qmu.Unlock()
+
+	if empty {
+		return
+	}
+
+	h.dmu.Lock()
+	defer h.dmu.Unlock()
+
+	for {
+		h.qmu.Lock()
+		queue := h.queue
+		h.queue = nil
+		subs := append([]*subscriber[T](nil), h.subs...)
+		h.qmu.Unlock()
+
+		if len(queue) == 0 {
+			return
+		}
+
+		for _, q := range queue {
+			for _, sub := range subs {
+				if !sub.closed && q.seq >= sub.from && sub.match(q.msg) {
+					h.deliver(sub, q.msg)
+				}
+			}
+		}
+	}
+}
+
+// deliver is a function which sends the message to the subscriber according to its overflow policy.
+// It never blocks, so dmu is not held while waiting for subscribers.
+// The caller must hold dmu.
+func (h *hub[T]) deliver(sub *subscriber[T], msg T) {
+	if sub.overflow == OverflowQueue {
+		if !sub.enqueue(msg) {
+			h.unsubscribe(sub)
+		}
+		return
+	}
+
+	select {
+	case sub.ch <- msg:
+		return
+	default:
+	}
+
+	if sub.overflow == OverflowDisconnect {
+		h.unsubscribe(sub)
+	}
+}
+
+// enqueue is a function which sends the message to the channel of OverflowQueue subscriber,
+// or appends it to the backlog if the buffer is full or earlier messages are still in the backlog.
+// It returns false if the backlog is full.
+func (sub *subscriber[T]) enqueue(msg T) bool {
+	sub.bmu.Lock()
+	defer sub.bmu.Unlock()
+
+	if len(sub.backlog) == 0 {
+		select {
+		case sub.ch <- msg:
+			return true
+		default:
+		}
+	}
+
+	if len(sub.backlog) >= sub.queueLimit {
+		return false
+	}
+
+	sub.backlog = append(sub.backlog, msg)
+
+	select {
+	case sub.signal <- struct{}{}:
+	default:
+	}
+
+	return true
+}
+
+// pump is a function which sends the backlog of OverflowQueue subscriber to its channel in order,
+// until the subscriber is removed.
+// A message is removed from the backlog only after it is sent, so `enqueue` never overtakes it.
+func (sub *subscriber[T]) pump() {
+	defer close(sub.stopped)
+
+	for {
+		sub.bmu.Lock()
+		pending := len(sub.backlog) > 0
+		var msg T
+		if pending {
+			msg = sub.backlog[0]
+		}
+		sub.bmu.Unlock()
+
+		if !pending {
+			select {
+			case <-sub.signal:
+				continue
+			case <-sub.done:
+				return
+			}
+		}
+
+		select {
+		case sub.ch <- msg:
+		case <-sub.done:
+			return
+		}
+
+		sub.bmu.Lock()
+		sub.backlog[0] = *new(T) // release the reference
+		sub.backlog = sub.backlog[1:]
+		sub.bmu.Unlock()
+	}
+}
@@ -0,0 +1,100 @@
+package skhron
+
+import (
+	"maps"
+	"slices"
+)
+
+// secondaryIndex maps values extracted from stored values to keys holding them.
+// It is not safe for concurrent use, Skhron mutex protects it.
+type secondaryIndex[V any] struct {
+	extract func(V) []string
+	keys    map[string]map[string]struct{} // extracted value -> keys
+	terms   map[string][]string            // key -> extracted values
+}
+
+func newSecondaryIndex[V any](extract func(V) []string) *secondaryIndex[V] {
+	return &secondaryIndex[V]{
+		extract: extract,
+		keys:    make(map[string]map[string]struct{}),
+		terms:   make(map[string][]string),
+	}
+}
+
+// insert is a function which indexes the value stored under the key, replacing its previous terms.
+func (x *secondaryIndex[V]) insert(key string, value V) {
+	x.delete(key)
+
+	terms := x.extract(value)
+	if len(terms) == 0 {
+		return
+	}
+
+	for _, term := range terms {
+		keys, ok := x.keys[term]
+		if !ok {
+			keys = make(map[string]struct{})
+			x.keys[term] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	x.terms[key] = slices.Clone(terms)
+}
+
+// delete is a function which removes the key from the index.
+func (x *secondaryIndex[V]) delete(key string) {
+	for _, term := range x.terms[key] {
+		keys := x.keys[term]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(x.keys, term)
+		}
+	}
+	delete(x.terms, key)
+}
+
+// AddIndex is a function which adds a secondary index named `name`, so values can be found
+// by the strings `extract` returns for them (see `FindBy`). Existing values are indexed immediately,
+// values put later are indexed on put and removed from the index on delete and expiry.
+// `extract` runs while the lock is held, so it must be fast and must not call the storage.
+// An index with the same name is replaced.
+// Indexes are not persisted in snapshots: they are rebuilt when a snapshot is loaded.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) AddIndex(name string, extract func(V) []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := newSecondaryIndex(extract)
+	for key, value := range s.Data.Values() {
+		index.insert(key, value)
+	}
+
+	if s.indexes == nil {
+		s.indexes = make(map[string]*secondaryIndex[V])
+	}
+	s.indexes[name] = index
+}
+
+// FindBy is a function which returns entries sorted by keys, for which the extract function
+// of the index returned `value`. Expired keys are skipped. If there is no such index, no entries are returned.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) FindBy(index, value string) []Entry[V] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]Entry[V], 0)
+
+	x, ok := s.indexes[index]
+	if !ok {
+		return entries
+	}
+
+	now := s.Clock.Now()
+	for _, key := range slices.Sorted(maps.Keys(x.keys[value])) {
+		if v, ok := s.Data.Get2(key); ok && !s.expired(key, now) {
+			entries = append(entries, s.entry(key, v))
+		}
+	}
+
+	return entries
+}
@@ -0,0 +1,94 @@
+package skhron_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/skhrontest"
+)
+
+type user struct {
+	Name  string   `json:"name"`
+	Email string   `json:"email"`
+	Tags  []string `json:"tags"`
+}
+
+func foundKeys(entries []skhron.Entry[user]) []string {
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+func TestSecondaryIndex(t *testing.T) {
+	dir := t.TempDir()
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts := []skhron.StorageOpt[user]{
+		skhron.WithClock[user](clock),
+		skhron.WithSnapshotDir[user](dir),
+		skhron.WithTempSnapshotDir[user](dir),
+	}
+	storage := skhron.New(opts...)
+
+	storage.Put("u1", user{Name: "alice", Email: "alice@example.com", Tags: []string{"admin", "dev"}})
+
+	byEmail := func(u user) []string { return []string{u.Email} }
+	byTag := func(u user) []string { return u.Tags }
+
+	// existing values are indexed when the index is added
+	storage.AddIndex("email", byEmail)
+	storage.AddIndex("tag", byTag)
+
+	storage.Put("u2", user{Name: "bob", Email: "bob@example.com", Tags: []string{"dev"}})
+	storage.PutTTL("u3", user{Name: "carol", Email: "carol@example.com", Tags: []string{"dev"}}, time.Minute)
+
+	if got := storage.FindBy("email", "bob@example.com"); len(got) != 1 || got[0].Value.Name != "bob" {
+		t.Errorf("FindBy(email, bob) = %v", got)
+	}
+	if got := foundKeys(storage.FindBy("tag", "dev")); !slices.Equal(got, []string{"u1", "u2", "u3"}) {
+		t.Errorf("FindBy(tag, dev) = %v", got)
+	}
+	if got := storage.FindBy("unknown", "dev"); len(got) != 0 {
+		t.Errorf("FindBy(unknown) = %v, want empty", got)
+	}
+
+	// updates replace indexed values
+	storage.Put("u1", user{Name: "alice", Email: "alice@example.org", Tags: []string{"admin"}})
+	if got := storage.FindBy("email", "alice@example.com"); len(got) != 0 {
+		t.Errorf("FindBy(email) of replaced value = %v, want empty", got)
+	}
+	if got := foundKeys(storage.FindBy("email", "alice@example.org")); !slices.Equal(got, []string{"u1"}) {
+		t.Errorf("FindBy(email, alice) = %v", got)
+	}
+
+	// deleted and expired keys are removed from the index
+	storage.Delete("u2")
+	clock.Advance(time.Minute + time.Second)
+	if got := foundKeys(storage.FindBy("tag", "dev")); len(got) != 0 {
+		t.Errorf("FindBy(tag, dev) with expired key = %v, want empty", got)
+	}
+	storage.CleanUpN(0)
+
+	storage.Put("u4", user{Name: "dave", Email: "dave@example.com", Tags: []string{"dev"}})
+	if err := storage.CreateSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	// indexes are rebuilt after the snapshot is loaded
+	restored := skhron.New(opts...)
+	restored.AddIndex("tag", byTag)
+	restored.Put("stale", user{Tags: []string{"dev"}})
+	if err := restored.LoadSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := foundKeys(restored.FindBy("tag", "dev")); !slices.Equal(got, []string{"u4"}) {
+		t.Errorf("restored FindBy(tag, dev) = %v", got)
+	}
+	if got := foundKeys(restored.FindBy("tag", "admin")); !slices.Equal(got, []string{"u1"}) {
+		t.Errorf("restored FindBy(tag, admin) = %v", got)
+	}
+}
@@ -0,0 +1,243 @@
+package skhron
+
+import (
+	"iter"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Entry is a key-value pair with the expiration time of the key.
+// Zero Exp means the key does not expire.
+type Entry[V any] struct {
+	Key   string
+	Value V
+	Exp   time.Time
+}
+
+// iterChunk is a number of keys whose values are read under a single lock acquisition during iteration.
+const iterChunk = 256
+
+// All is a function which returns an iterator over all key-value pairs in the storage.
+// Expired items which are not yet removed by cleanup are skipped.
+//
+// Iteration is weakly consistent: the set of keys is captured when iteration starts,
+// keys put afterwards are not visited, keys deleted or expired before they are reached are skipped,
+// and values are read from the live storage shortly before they are yielded.
+// The lock is never held while yielding, so the loop body may modify the storage.
+// The order of iteration is not specified.
+func (s *Skhron[V]) All() iter.Seq2[string, V] {
+	return s.scan(nil)
+}
+
+// Keys is a function which returns an iterator over keys in the storage.
+// It has the same consistency semantics as `All`.
+func (s *Skhron[V]) Keys() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for key := range s.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Values is a function which returns an iterator over values in the storage.
+// It has the same consistency semantics as `All`.
+func (s *Skhron[V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, value := range s.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// ScanPrefix is a function which returns an iterator over key-value pairs
+// whose keys start with `prefix`.
+// With skhron.WithOrderedIndex option keys are visited in ascending order
+// and only matching keys are touched, otherwise every key is checked.
+// It has the same consistency semantics as `All`.
+func (s *Skhron[V]) ScanPrefix(prefix string) iter.Seq2[string, V] {
+	if s.ordered != nil {
+		return s.Range(prefix, prefixEnd(prefix))
+	}
+
+	return s.scan(func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+// Range is a function which returns an iterator over key-value pairs
+// whose keys are in range [start, end) in ascending order. Empty `end` means no upper bound.
+// With skhron.WithOrderedIndex option it takes O(log n + k), otherwise all keys are sorted first.
+// Keys are read from the index in chunks, so keys put during iteration
+// may be visited if they are greater than the last visited key.
+// Other consistency semantics are the same as in `All`.
+func (s *Skhron[V]) Range(start, end string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		if s.ordered == nil {
+			s.mu.RLock()
+			keys := s.sortedKeys(start, end)
+			s.mu.RUnlock()
+
+			s.yieldKeys(keys, yield)
+			return
+		}
+
+		for from := start; ; {
+			s.mu.RLock()
+			keys := s.ordered.ascend(from, end, iterChunk)
+			s.mu.RUnlock()
+
+			if !s.yieldKeys(keys, yield) || len(keys) < iterChunk {
+				return
+			}
+
+			from = keys[len(keys)-1] + "\x00" // the smallest key after the last one
+		}
+	}
+}
+
+// RangeReverse is a function which returns an iterator over key-value pairs
+// whose keys are in range [start, end) in descending order. Empty `end` means no upper bound.
+// It has the same complexity and consistency semantics as `Range`.
+func (s *Skhron[V]) RangeReverse(start, end string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		if s.ordered == nil {
+			s.mu.RLock()
+			keys := s.sortedKeys(start, end)
+			s.mu.RUnlock()
+
+			slices.Reverse(keys)
+			s.yieldKeys(keys, yield)
+			return
+		}
+
+		for to := end; ; {
+			s.mu.RLock()
+			keys := s.ordered.descend(start, to, iterChunk)
+			s.mu.RUnlock()
+
+			if !s.yieldKeys(keys, yield) || len(keys) < iterChunk {
+				return
+			}
+
+			to = keys[len(keys)-1]
+			if to == "" {
+				return
+			}
+		}
+	}
+}
+
+// Page is a function which returns at most `limit` entries with keys greater or equal to `cursor`
+// in ascending order, and the cursor of the next page.
+// Empty cursor means the first page. The next cursor is empty when there are no more keys.
+// With skhron.WithOrderedIndex option it takes O(log n + limit), otherwise all keys are sorted first.
+// Expired items are skipped.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) Page(cursor string, limit int) ([]Entry[V], string) {
+	if limit <= 0 {
+		return []Entry[V]{}, ""
+	}
+
+	return s.find(nil, "", FindOpts{Limit: limit, Cursor: cursor})
+}
+
+// sortedKeys is a function which returns sorted keys in range [start, end)
+// by scanning the entire storage. Empty `end` means no upper bound.
+// The caller must hold the lock.
+func (s *Skhron[V]) sortedKeys(start, end string) []string {
+	keys := make([]string, 0)
+	for key := range s.Data.Values() {
+		if key >= start && (end == "" || key < end) {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+	return keys
+}
+
+// prefixEnd is a function which returns the smallest string greater than all strings with `prefix`,
+// or empty string if there is no such string.
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	return ""
+}
+
+// scan is a function which returns an iterator over key-value pairs whose keys satisfy `match`
+// (all pairs, if `match` is nil). Only keys are copied when iteration starts,
+// values are read in chunks of `iterChunk` keys under the read lock.
+func (s *Skhron[V]) scan(match func(key string) bool) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		s.mu.RLock()
+		keys := make([]string, 0, len(s.Data.Values()))
+		for key := range s.Data.Values() {
+			if match == nil || match(key) {
+				keys = append(keys, key)
+			}
+		}
+		s.mu.RUnlock()
+
+		s.yieldKeys(keys, yield)
+	}
+}
+
+// yieldKeys is a function which yields live values of `keys` in the given order,
+// skipping missing and expired keys. It returns false if `yield` stopped the iteration.
+func (s *Skhron[V]) yieldKeys(keys []string, yield func(string, V) bool) bool {
+	type pair struct {
+		key   string
+		value V
+	}
+
+	pairs := make([]pair, 0, min(len(keys), iterChunk))
+
+	for start := 0; start < len(keys); start += iterChunk {
+		pairs = pairs[:0]
+
+		s.mu.RLock()
+		now := s.Clock.Now()
+		for _, key := range keys[start:min(start+iterChunk, len(keys))] {
+			if value, ok := s.Data.Get2(key); ok && !s.expired(key, now) {
+				pairs = append(pairs, pair{key, value})
+			}
+		}
+		s.mu.RUnlock()
+
+		for _, p := range pairs {
+			if !yield(p.key, p.value) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// entry is a function which creates an entry of the key with its expiration time.
+// The caller must hold the lock.
+func (s *Skhron[V]) entry(key string, value V) Entry[V] {
+	e := Entry[V]{Key: key, Value: value}
+	if item, ok := s.ttlIndex[key]; ok {
+		e.Exp = item.Exp
+	}
+	return e
+}
+
+// expired is a function which checks whether the key has expired by `now`,
+// but has not been removed by cleanup yet.
+// The caller must hold the lock.
+func (s *Skhron[V]) expired(key string, now time.Time) bool {
+	item, ok := s.ttlIndex[key]
+	return ok && item.Exp.Before(now)
+}
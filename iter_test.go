@@ -0,0 +1,124 @@
+package skhron
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIterators(t *testing.T) {
+	s := New[int]()
+
+	s.Put("user:1", 1)
+	s.Put("user:2", 2)
+	s.Put("group:1", 3)
+	s.PutTTL("user:3", 4, time.Hour)
+	s.PutTTL("user:expired", 5, -time.Second) // expired, but not cleaned up yet
+
+	t.Run("All", func(t *testing.T) {
+		want := map[string]int{"user:1": 1, "user:2": 2, "group:1": 3, "user:3": 4}
+		if got := maps.Collect(s.All()); !reflect.DeepEqual(got, want) {
+			t.Errorf("All() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Keys", func(t *testing.T) {
+		want := []string{"group:1", "user:1", "user:2", "user:3"}
+		if got := slices.Sorted(s.Keys()); !reflect.DeepEqual(got, want) {
+			t.Errorf("Keys() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Values", func(t *testing.T) {
+		want := []int{1, 2, 3, 4}
+		if got := slices.Sorted(s.Values()); !reflect.DeepEqual(got, want) {
+			t.Errorf("Values() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("ScanPrefix", func(t *testing.T) {
+		want := map[string]int{"user:1": 1, "user:2": 2, "user:3": 4}
+		if got := maps.Collect(s.ScanPrefix("user:")); !reflect.DeepEqual(got, want) {
+			t.Errorf("ScanPrefix() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Break", func(t *testing.T) {
+		count := 0
+		for range s.All() {
+			count++
+			break
+		}
+		if count != 1 {
+			t.Errorf("iteration did not stop, %d items visited", count)
+		}
+	})
+}
+
+func TestIteratorsConcurrentWrites(t *testing.T) {
+	s := New[int]()
+
+	for i := 0; i < 3*iterChunk; i++ {
+		s.Put(string(rune('a'+i)), i)
+	}
+
+	// the lock is not held while yielding, so the storage can be modified inside the loop
+	visited := 0
+	for key := range s.Keys() {
+		visited++
+		if err := s.Delete(key); err != nil {
+			t.Fatalf("delete failed: %v", err)
+		}
+		s.Put("new-"+key, 0) // keys put during iteration are not visited
+	}
+
+	if visited != 3*iterChunk {
+		t.Errorf("visited %d keys, want %d", visited, 3*iterChunk)
+	}
+}
+
+func TestIteratorsConcurrentLoad(t *testing.T) {
+	dir := t.TempDir()
+	opts := []StorageOpt[int]{
+		WithOrderedIndex[int](),
+		WithSnapshotDir[int](dir),
+		WithTempSnapshotDir[int](dir),
+	}
+	s := New(opts...)
+
+	for i := 0; i < iterChunk; i++ {
+		s.Put(string(rune('a'+i)), i)
+	}
+	if err := s.CreateSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			if err := s.LoadSnapshot(); err != nil {
+				t.Errorf("load snapshot failed: %v", err)
+			}
+		}
+	}()
+
+	for loading := true; loading; {
+		select {
+		case <-done:
+			loading = false
+		default:
+		}
+
+		for range s.ScanPrefix("a") {
+		}
+		for range s.RangeReverse("", "") {
+		}
+	}
+
+	if n := len(slices.Collect(s.Keys())); n != iterChunk {
+		t.Errorf("got %d keys after load, want %d", n, iterChunk)
+	}
+}
@@ -0,0 +1,64 @@
+package skhron
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// PutOpt is an option of a single put operation.
+type PutOpt func(o *putOptions)
+
+type putOptions struct {
+	jitter float64
+}
+
+// WithJitter is an option which randomizes the TTL of the put value by up to ±`fraction` of it,
+// overriding `TTLJitter` of the storage (skhron.WithTTLJitter option). Zero fraction disables jitter.
+func WithJitter(fraction float64) PutOpt {
+	return func(o *putOptions) {
+		o.jitter = fraction
+	}
+}
+
+// putOptions is a function which applies put options over the defaults of the storage.
+func (s *Skhron[V]) putOptions(opts []PutOpt) putOptions {
+	o := putOptions{jitter: s.TTLJitter}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// jitter is a function which randomizes `ttl` uniformly within ±`fraction` of it.
+// The fraction is clamped to [0, 1].
+// The caller must hold the write lock, since the random source is not safe for concurrent use.
+func (s *Skhron[V]) jitter(ttl time.Duration, fraction float64) time.Duration {
+	fraction = min(max(fraction, 0), 1)
+	if fraction == 0 || ttl <= 0 {
+		return ttl
+	}
+
+	return ttl + time.Duration(float64(ttl)*fraction*(2*s.rng.Float64()-1))
+}
+
+// expireTTL is a function which sets expiration of the key put with TTL duration.
+// If sliding expiration is enabled (skhron.WithSlidingExpiration option), reads extend the TTL,
+// otherwise the TTL is randomized by `jitter` fraction. Sliding TTLs are never jittered.
+// The caller must hold the write lock.
+func (s *Skhron[V]) expireTTL(key string, now time.Time, ttl time.Duration, jitter float64) {
+	if s.SlidingExpiration {
+		s.expireAfter(key, now, ttl, true)
+		return
+	}
+
+	s.expireAfter(key, now, s.jitter(ttl, jitter), false)
+}
+
+// newRand is a function which creates a random source for TTL jitter.
+// Zero seed means a random seed.
+func newRand(seed uint64) *rand.Rand {
+	if seed == 0 {
+		return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return rand.New(rand.NewPCG(seed, seed))
+}
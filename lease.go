@@ -0,0 +1,182 @@
+package skhron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrLeaseHeld is returned (wrapped with the key) when the lease is held by another owner.
+	ErrLeaseHeld = errors.New("lease is held by another owner")
+	// ErrLeaseLost is returned (wrapped with the key) when the lease has expired,
+	// was released or was acquired by someone else.
+	ErrLeaseLost = errors.New("lease is lost")
+)
+
+// Lease is an exclusive right of the owner to a key until it expires.
+type Lease struct {
+	Key   string `json:"key"`
+	Owner string `json:"owner"`
+	// Token is a fencing token: it increases with every acquisition of any lease in the storage
+	// and does not change on renewal. Resources protected by the lease should reject
+	// requests with a token lower than the last one they have seen.
+	Token   uint64    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+// Leases is a set of leases stored in Skhron[Lease]. Leases expire via the storage TTL,
+// so a crashed owner never holds a lease forever.
+type Leases struct {
+	s *Skhron[Lease]
+}
+
+// NewLeases is a function which creates leases stored in `s`.
+func NewLeases(s *Skhron[Lease]) *Leases {
+	return &Leases{s: s}
+}
+
+// AcquireLease is a function which acquires the lease of the key for `ttl`.
+// If the lease is held by another owner, ErrLeaseHeld is returned with the current lease.
+// If it is already held by the owner, it is renewed keeping its token.
+// This function locks mutex for its operations.
+func (l *Leases) AcquireLease(key, owner string, ttl time.Duration) (Lease, error) {
+	s := l.s
+
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Clock.Now()
+
+	current, held := l.held(key, now)
+	if held && current.Owner != owner {
+		return current, fmt.Errorf("%w: %s", ErrLeaseHeld, key)
+	}
+
+	if !held {
+		if err := s.admit(key); err != nil {
+			return Lease{}, err
+		}
+		// the token is the version the lease is put with, so it exceeds all previous versions and tokens
+		current = Lease{Key: key, Owner: owner, Token: s.seq + 1}
+	}
+
+	return l.put(current, now, ttl), nil
+}
+
+// Acquire is a function which waits until the lease of the key is acquired or `ctx` is done.
+// Instead of polling, it waits for the release or expiry of the current lease.
+func (l *Leases) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (Lease, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// subscribe before the first attempt, so a release between the attempt and the wait is not missed
+	events := l.s.Watch(ctx, MatchKey(key), WithBuffer(1))
+
+	for {
+		current, err := l.AcquireLease(key, owner, ttl)
+		if !errors.Is(err, ErrLeaseHeld) {
+			return current, err
+		}
+
+		// the expiry is also awaited directly, in case the storage cleanup is not running
+		timer := l.s.Clock.NewTimer(current.Expires.Sub(l.s.Clock.Now()))
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return Lease{}, ctx.Err()
+			case <-timer.C():
+				break wait
+			case e, ok := <-events:
+				if !ok {
+					timer.Stop()
+					return Lease{}, ctx.Err()
+				}
+				if e.Type != EventPut {
+					timer.Stop()
+					break wait
+				}
+			}
+		}
+	}
+}
+
+// Renew is a function which extends the lease by `ttl` from now.
+// If the lease is lost, ErrLeaseLost is returned.
+// This function locks mutex for its operations.
+func (l *Leases) Renew(lease Lease, ttl time.Duration) (Lease, error) {
+	s := l.s
+
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Clock.Now()
+
+	current, held := l.held(lease.Key, now)
+	if !held || current.Token != lease.Token {
+		return Lease{}, fmt.Errorf("%w: %s", ErrLeaseLost, lease.Key)
+	}
+
+	return l.put(current, now, ttl), nil
+}
+
+// Release is a function which releases the lease, so others may acquire it.
+// If the lease is lost, ErrLeaseLost is returned.
+// This function locks mutex for its operations.
+func (l *Leases) Release(lease Lease) error {
+	s := l.s
+
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, held := l.held(lease.Key, s.Clock.Now())
+	if !held || current.Token != lease.Token {
+		return fmt.Errorf("%w: %s", ErrLeaseLost, lease.Key)
+	}
+
+	s.remove(lease.Key, EventDelete)
+
+	return nil
+}
+
+// Get is a function which returns the current lease of the key.
+// If the lease is not held, ErrNoSuchKey is returned.
+// This function locks mutex (for reading) for its operations.
+func (l *Leases) Get(key string) (Lease, error) {
+	l.s.mu.RLock()
+	defer l.s.mu.RUnlock()
+
+	if current, held := l.held(key, l.s.Clock.Now()); held {
+		return current, nil
+	}
+
+	return Lease{}, noSuchKey(key)
+}
+
+// held is a function which returns the lease of the key, if it has not expired by `now`.
+// The caller must hold the lock (for reading at least).
+func (l *Leases) held(key string, now time.Time) (Lease, bool) {
+	current, ok := l.s.Data.Get2(key)
+	if !ok || !now.Before(current.Expires) {
+		return Lease{}, false
+	}
+	return current, true
+}
+
+// put is a function which stores the lease expiring after `ttl` from `now`.
+// The caller must hold the write lock.
+func (l *Leases) put(lease Lease, now time.Time, ttl time.Duration) Lease {
+	lease.Expires = now.Add(ttl)
+
+	l.s.set(lease.Key, lease)
+	l.s.schedule(lease.Key, lease.Expires)
+
+	return lease
+}
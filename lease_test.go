@@ -0,0 +1,121 @@
+package skhron_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/skhrontest"
+)
+
+func TestLeases(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	leases := skhron.NewLeases(skhron.New(skhron.WithClock[skhron.Lease](clock)))
+
+	first, err := leases.AcquireLease("job", "alice", time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireLease failed: %v", err)
+	}
+
+	if current, err := leases.AcquireLease("job", "bob", time.Minute); !errors.Is(err, skhron.ErrLeaseHeld) || current.Owner != "alice" {
+		t.Errorf("AcquireLease by another owner = %+v, %v, want lease of alice, %v", current, err, skhron.ErrLeaseHeld)
+	}
+	if err := leases.Release(skhron.Lease{Key: "job", Owner: "bob"}); !errors.Is(err, skhron.ErrLeaseLost) {
+		t.Errorf("Release by another owner = %v, want %v", err, skhron.ErrLeaseLost)
+	}
+
+	clock.Advance(50 * time.Second)
+	renewed, err := leases.Renew(first, time.Minute)
+	if err != nil || renewed.Token != first.Token {
+		t.Fatalf("Renew = %+v, %v, want the same token %d", renewed, err, first.Token)
+	}
+
+	// the renewed lease outlives the original TTL
+	clock.Advance(50 * time.Second)
+	if _, err := leases.AcquireLease("job", "bob", time.Minute); !errors.Is(err, skhron.ErrLeaseHeld) {
+		t.Errorf("renewed lease was acquired by another owner: %v", err)
+	}
+
+	if err := leases.Release(renewed); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if err := leases.Release(renewed); !errors.Is(err, skhron.ErrLeaseLost) {
+		t.Errorf("second Release = %v, want %v", err, skhron.ErrLeaseLost)
+	}
+
+	second, err := leases.AcquireLease("job", "bob", time.Minute)
+	if err != nil || second.Token <= first.Token {
+		t.Errorf("AcquireLease after release = %+v, %v, want token greater than %d", second, err, first.Token)
+	}
+
+	// expired lease is lost even before cleanup
+	clock.Advance(time.Minute)
+	if _, err := leases.Renew(second, time.Minute); !errors.Is(err, skhron.ErrLeaseLost) {
+		t.Errorf("Renew of expired lease = %v, want %v", err, skhron.ErrLeaseLost)
+	}
+}
+
+func TestLeaseAcquireWaitsForRelease(t *testing.T) {
+	leases := skhron.NewLeases(skhron.New[skhron.Lease]())
+
+	held, _ := leases.AcquireLease("job", "alice", time.Hour)
+
+	acquired := make(chan skhron.Lease)
+	go func() {
+		lease, err := leases.Acquire(context.Background(), "job", "bob", time.Hour)
+		if err != nil {
+			t.Errorf("Acquire failed: %v", err)
+		}
+		acquired <- lease
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatalf("lease was acquired while it was held")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	leases.Release(held)
+
+	select {
+	case lease := <-acquired:
+		if lease.Owner != "bob" || lease.Token <= held.Token {
+			t.Errorf("Acquire = %+v, want lease of bob with token greater than %d", lease, held.Token)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("lease was not acquired after release")
+	}
+}
+
+func TestLeaseAcquireWaitsForExpiry(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	leases := skhron.NewLeases(skhron.New(skhron.WithClock[skhron.Lease](clock)))
+
+	leases.AcquireLease("job", "alice", time.Minute)
+
+	acquired := make(chan error)
+	go func() {
+		_, err := leases.Acquire(context.Background(), "job", "bob", time.Minute)
+		acquired <- err
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Errorf("Acquire failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("lease was not acquired after expiry")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := leases.Acquire(ctx, "job", "carol", time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire with canceled context = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,148 @@
+package skhron
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sync"
+)
+
+// ErrClosed is returned by Close when the storage is not running.
+var ErrClosed = errors.New("skhron is not running")
+
+// lifecycle is a state of background workers owned by Skhron.
+type lifecycle struct {
+	mu      sync.Mutex
+	cancel  context.CancelFunc // stops background workers, nil if they are not running
+	workers sync.WaitGroup
+}
+
+// Open is a function which initializes Skhron instance with options and starts it.
+// If persistence is enabled (skhron.WithPersistence option), the latest snapshot is loaded.
+// A missing snapshot file is not an error.
+// It starts background workers: the expiry scheduler and, if `SnapshotInterval` is set
+// (skhron.WithSnapshotInterval option), periodic snapshots.
+// The storage must be stopped with `Close`.
+func Open[V any](opts ...StorageOpt[V]) (*Skhron[V], error) {
+	s := New(opts...)
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// open is a function which loads the latest snapshot (if persistence is enabled) and starts the storage.
+func (s *Skhron[V]) open() error {
+	if s.Persistent {
+		if err := s.LoadSnapshot(); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	s.start()
+
+	return nil
+}
+
+// running is a function which reports whether background workers are running.
+// Namespaces are running while their parent is running.
+func (s *Skhron[V]) running() bool {
+	if s.parent != nil {
+		return s.parent.running()
+	}
+
+	s.life.mu.Lock()
+	defer s.life.mu.Unlock()
+
+	return s.life.cancel != nil
+}
+
+// start is a function which runs background workers in goroutines, unless they are already running.
+// The workers serve namespaces of the storage as well.
+func (s *Skhron[V]) start() {
+	s.life.mu.Lock()
+	defer s.life.mu.Unlock()
+
+	if s.life.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.life.cancel = cancel
+
+	s.life.workers.Add(1)
+	go func() {
+		defer s.life.workers.Done()
+		s.RunExpiryScheduler(ctx)
+	}()
+
+	if s.Persistent && s.SnapshotInterval > 0 {
+		s.life.workers.Add(1)
+		go func() {
+			defer s.life.workers.Done()
+			s.runSnapshots(ctx)
+		}()
+	}
+}
+
+// runSnapshots is a function which creates a snapshot (with snapshots of namespaces) every `SnapshotInterval`.
+// It works until `ctx.Done()` signal is sent.
+func (s *Skhron[V]) runSnapshots(ctx context.Context) {
+	timer := s.Clock.NewTimer(s.SnapshotInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C():
+			if err := s.CreateSnapshot(); err != nil {
+				log.Printf("failed to create snapshot file: %v\n", err)
+			}
+			timer.Reset(s.SnapshotInterval)
+		}
+	}
+}
+
+// Close is a function which stops background workers started by `Open`.
+// It waits for the workers, removes expired items of the storage and its namespaces
+// and, if persistence is enabled, creates the final snapshot (with snapshots of namespaces).
+// If `ctx` is done before the workers stop, `ctx.Err()` is returned without the final snapshot,
+// since a worker may still be writing one.
+// Otherwise, the error of the final snapshot is returned.
+// If the storage is not running (or it is a namespace, which is closed by its parent), ErrClosed is returned.
+func (s *Skhron[V]) Close(ctx context.Context) error {
+	s.life.mu.Lock()
+	cancel := s.life.cancel
+	s.life.cancel = nil
+	s.life.mu.Unlock()
+
+	if cancel == nil {
+		return ErrClosed
+	}
+
+	cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.life.workers.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.cleanUpAll()
+
+	if s.Persistent {
+		return s.CreateSnapshot()
+	}
+
+	return nil
+}
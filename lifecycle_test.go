@@ -0,0 +1,135 @@
+package skhron_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/skhrontest"
+)
+
+func TestOpenClose(t *testing.T) {
+	dir := t.TempDir()
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts := []skhron.StorageOpt[string]{
+		skhron.WithClock[string](clock),
+		skhron.WithSnapshotDir[string](dir),
+		skhron.WithTempSnapshotDir[string](dir),
+		skhron.WithSnapshotInterval[string](time.Hour),
+	}
+
+	storage, err := skhron.Open(opts...)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	storage.Put("forever", "value")
+	storage.PutTTL("short", "value", time.Second)
+
+	// expiry scheduler is started by Open
+	clock.Advance(time.Minute)
+	waitFor(t, func() bool { return !storage.Exists("short") })
+
+	if err := storage.Close(context.Background()); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	// stopped workers do not leave their timers behind
+	if n := clock.Waiters(); n != 0 {
+		t.Errorf("Waiters() after close = %d, want 0", n)
+	}
+
+	if err := storage.Close(context.Background()); !errors.Is(err, skhron.ErrClosed) {
+		t.Errorf("second close = %v, want %v", err, skhron.ErrClosed)
+	}
+
+	if _, err := os.Stat(path.Join(dir, "snapshot"+skhron.SkhronExtension)); err != nil {
+		t.Fatalf("snapshot was not created: %v", err)
+	}
+
+	reopened, err := skhron.Open(opts...)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close(context.Background())
+
+	if v, err := reopened.Get("forever"); err != nil || v != "value" {
+		t.Errorf("Get(forever) = %v, %v, want value", v, err)
+	}
+}
+
+func TestOpenWithoutPersistence(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := skhron.Open(
+		skhron.WithSnapshotDir[int](dir),
+		skhron.WithPersistence[int](false),
+	)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	storage.Put("key", 1)
+
+	if err := storage.Close(context.Background()); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected no snapshot files, got %d", len(entries))
+	}
+}
+
+// blockingCodec is a codec which blocks encoding until `release` is closed.
+type blockingCodec struct {
+	calls   *atomic.Int32
+	release chan struct{}
+}
+
+func (c blockingCodec) Marshal(value int) ([]byte, error) {
+	c.calls.Add(1)
+	<-c.release
+	return skhron.JSONCodec[int]{}.Marshal(value)
+}
+
+func (c blockingCodec) Unmarshal(data []byte) (int, error) {
+	return skhron.JSONCodec[int]{}.Unmarshal(data)
+}
+
+func TestCloseTimeout(t *testing.T) {
+	dir := t.TempDir()
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	codec := blockingCodec{calls: new(atomic.Int32), release: make(chan struct{})}
+	storage, err := skhron.Open(
+		skhron.WithClock[int](clock),
+		skhron.WithSnapshotDir[int](dir),
+		skhron.WithTempSnapshotDir[int](dir),
+		skhron.WithSnapshotInterval[int](time.Minute),
+		skhron.WithValueCodec[int](codec),
+	)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	storage.Put("key", 1)
+
+	// the periodic snapshot is stuck in the middle of writing
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	waitFor(t, func() bool { return codec.calls.Load() == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := storage.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(codec.release)
+	if n := codec.calls.Load(); n != 1 {
+		t.Errorf("final snapshot was written while the worker was running (%d encodings)", n)
+	}
+}
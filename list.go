@@ -0,0 +1,144 @@
+package skhron
+
+import "slices"
+
+// LPush is a function which inserts values at the head of the list stored under the key
+// (one after another, so the last value becomes the head). A missing list is created.
+// It returns the length of the list.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) LPush(key string, values ...V) (int, error) {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.writeCollection(key, CollectionList, len(values) > 0)
+	if err != nil || c == nil {
+		return 0, err
+	}
+
+	head := slices.Clone(values)
+	slices.Reverse(head)
+	c.list = append(head, c.list...)
+
+	if len(values) > 0 {
+		s.changed(key, c)
+	}
+
+	return len(c.list), nil
+}
+
+// RPush is a function which appends values to the tail of the list stored under the key.
+// A missing list is created.
+// It returns the length of the list.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) RPush(key string, values ...V) (int, error) {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.writeCollection(key, CollectionList, len(values) > 0)
+	if err != nil || c == nil {
+		return 0, err
+	}
+
+	c.list = append(c.list, values...)
+
+	if len(values) > 0 {
+		s.changed(key, c)
+	}
+
+	return len(c.list), nil
+}
+
+// LPop is a function which removes and returns the head of the list stored under the key.
+// The list is removed when it becomes empty.
+// If the list is missing, ErrNoSuchKey is returned, if the key holds another type, ErrWrongType is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) LPop(key string) (V, error) {
+	return s.pop(key, true)
+}
+
+// RPop is a function which removes and returns the tail of the list stored under the key (see `LPop`).
+// This function locks mutex for its operations.
+func (s *Skhron[V]) RPop(key string) (V, error) {
+	return s.pop(key, false)
+}
+
+// LRange is a function which returns elements of the list stored under the key
+// from `start` to `stop` inclusive. Negative indexes count from the tail (-1 is the last element).
+// Indexes out of range are clamped, so a missing list is the same as an empty one.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) LRange(key string, start, stop int) ([]V, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.readCollection(key, CollectionList)
+	if err != nil || c == nil {
+		return []V{}, err
+	}
+
+	n := len(c.list)
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	start, stop = max(start, 0), min(stop, n-1)
+
+	if start > stop {
+		return []V{}, nil
+	}
+
+	return slices.Clone(c.list[start : stop+1]), nil
+}
+
+// LLen is a function which returns the length of the list stored under the key (zero if it is missing).
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) LLen(key string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.readCollection(key, CollectionList)
+	if err != nil || c == nil {
+		return 0, err
+	}
+
+	return len(c.list), nil
+}
+
+// pop is a function which removes and returns the head (or the tail) of the list stored under the key.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) pop(key string, head bool) (V, error) {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.writeCollection(key, CollectionList, false)
+	if err != nil {
+		return *new(V), err
+	}
+	if c == nil {
+		return *new(V), noSuchKey(key)
+	}
+
+	var value V
+	if head {
+		value = c.list[0]
+		c.list[0] = *new(V) // release the reference
+		c.list = c.list[1:]
+	} else {
+		last := len(c.list) - 1
+		value = c.list[last]
+		c.list[last] = *new(V)
+		c.list = c.list[:last]
+	}
+
+	s.changed(key, c)
+
+	return value, nil
+}
@@ -0,0 +1,216 @@
+package skhron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Loader is a function which loads a value of the key from the source of truth (e.g. a database).
+type Loader[V any] func(ctx context.Context, key string) (V, error)
+
+// LoadingOpt is an option of a loading cache (see NewLoadingCache).
+type LoadingOpt func(c *loadingConfig)
+
+type loadingConfig struct {
+	ttl          time.Duration
+	refreshAhead time.Duration
+	staleGrace   time.Duration
+	negativeTTL  time.Duration
+}
+
+// WithLoadTTL sets how long loaded values are fresh (default one minute).
+// Zero TTL means loaded values never expire.
+func WithLoadTTL(ttl time.Duration) LoadingOpt {
+	return func(c *loadingConfig) {
+		c.ttl = ttl
+	}
+}
+
+// WithRefreshAhead sets a window before the end of freshness, in which a read of the key
+// triggers asynchronous refresh, so hot keys are reloaded before they become stale.
+func WithRefreshAhead(window time.Duration) LoadingOpt {
+	return func(c *loadingConfig) {
+		c.refreshAhead = window
+	}
+}
+
+// WithStaleGrace sets how long a value is served after the end of freshness
+// while it is refreshed in background or while the loader fails.
+func WithStaleGrace(grace time.Duration) LoadingOpt {
+	return func(c *loadingConfig) {
+		c.staleGrace = grace
+	}
+}
+
+// WithNegativeTTL sets how long the cache remembers that a key is missing.
+// If the loader returns an error wrapping ErrNoSuchKey, a negative entry is put for `ttl`
+// (see Skhron.PutNegative) and reads of the key fail with ErrNegativeCached without calling the loader.
+// Zero TTL (default) disables negative caching.
+func WithNegativeTTL(ttl time.Duration) LoadingOpt {
+	return func(c *loadingConfig) {
+		c.negativeTTL = ttl
+	}
+}
+
+// LoadingCache is a read-through cache on top of Skhron.
+// Missing keys are loaded synchronously, concurrent loads of the same key are deduplicated.
+// Keys are stored with TTL of freshness plus stale grace, so a value is:
+//   - fresh and served as is before the refresh-ahead window;
+//   - fresh and served, triggering asynchronous refresh within the window;
+//   - stale and served, triggering asynchronous refresh within the grace period;
+//   - removed by the storage cleanup after the grace period.
+//
+// A failed refresh keeps the stale value until the grace period ends.
+type LoadingCache[V any] struct {
+	store  *Skhron[V]
+	loader Loader[V]
+	config loadingConfig
+
+	mu    sync.Mutex
+	calls map[string]*loadCall[V] // in-flight loads by key
+}
+
+// loadCall is a load of a key shared by concurrent readers.
+type loadCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// NewLoadingCache is a function which creates a loading cache storing values in `store`.
+func NewLoadingCache[V any](store *Skhron[V], loader Loader[V], opts ...LoadingOpt) *LoadingCache[V] {
+	config := loadingConfig{ttl: time.Minute}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return &LoadingCache[V]{
+		store:  store,
+		loader: loader,
+		config: config,
+		calls:  make(map[string]*loadCall[V]),
+	}
+}
+
+// Get is a function which returns a value of the key, loading it if it is missing.
+// Fresh and stale values are returned without waiting for the loader (see LoadingCache).
+// If the key is loaded synchronously, concurrent readers of the key wait for the same load and receive its result.
+// The load is not cancelled with `ctx` (its values are kept), so a reader which gives up does not fail
+// the others: each reader stops waiting when its own `ctx` is done.
+func (c *LoadingCache[V]) Get(ctx context.Context, key string) (V, error) {
+	value, exp, err := c.store.lookup(key)
+	if errors.Is(err, ErrNegativeCached) {
+		return *new(V), err
+	}
+	if err != nil {
+		return c.load(ctx, key)
+	}
+
+	if !exp.IsZero() {
+		freshUntil := exp.Add(-c.config.staleGrace)
+		if !c.store.Clock.Now().Before(freshUntil.Add(-c.config.refreshAhead)) {
+			c.refresh(context.WithoutCancel(ctx), key)
+		}
+	}
+
+	return value, nil
+}
+
+// Refresh is a function which reloads the key synchronously and stores the result.
+// On error the cached value is kept.
+func (c *LoadingCache[V]) Refresh(ctx context.Context, key string) (V, error) {
+	return c.load(ctx, key)
+}
+
+// Invalidate is a function which removes the key from the cache.
+func (c *LoadingCache[V]) Invalidate(key string) {
+	c.store.Delete(key)
+}
+
+// load is a function which starts a load of the key (unless it is already in flight) and waits for it
+// until `ctx` is done. The load is shared by readers, so it is not cancelled with `ctx`.
+func (c *LoadingCache[V]) load(ctx context.Context, key string) (V, error) {
+	call, leader := c.begin(key)
+	if leader {
+		go c.run(context.WithoutCancel(ctx), key, call)
+	}
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		return *new(V), ctx.Err()
+	}
+}
+
+// refresh is a function which starts asynchronous load of the key, unless it is already in flight.
+func (c *LoadingCache[V]) refresh(ctx context.Context, key string) {
+	if call, leader := c.begin(key); leader {
+		go c.run(ctx, key, call)
+	}
+}
+
+// begin is a function which returns the in-flight load of the key or registers a new one.
+// `leader` is true if the caller must run the new load.
+func (c *LoadingCache[V]) begin(key string) (call *loadCall[V], leader bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if call, ok := c.calls[key]; ok {
+		return call, false
+	}
+
+	call = &loadCall[V]{done: make(chan struct{})}
+	c.calls[key] = call
+
+	return call, true
+}
+
+// run is a function which runs the loader, stores the loaded value and completes the load.
+func (c *LoadingCache[V]) run(ctx context.Context, key string, call *loadCall[V]) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+
+		close(call.done)
+	}()
+
+	call.value, call.err = c.loader(ctx, key)
+	if errors.Is(call.err, ErrNoSuchKey) && c.config.negativeTTL > 0 {
+		c.store.PutNegative(key, c.config.negativeTTL)
+	}
+	if call.err != nil {
+		return
+	}
+
+	if c.config.ttl > 0 {
+		call.err = c.store.PutTTL(key, call.value, c.config.ttl+c.config.staleGrace)
+	} else {
+		call.err = c.store.Put(key, call.value)
+	}
+}
+
+// lookup is a function which fetches a value under a key with its expiration time
+// (zero if the key does not expire). Keys (and negative entries) which have expired,
+// but have not been removed by cleanup yet, are reported as missing.
+// If the key is not present, error is returned (see `Get`).
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) lookup(key string) (V, time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.expired(key, s.Clock.Now()) {
+		s.stats.misses.Add(1)
+		return *new(V), time.Time{}, noSuchKey(key)
+	}
+
+	value, ok := s.Data.Get2(key)
+	if err := s.missing(key, ok); err != nil {
+		return *new(V), time.Time{}, err
+	}
+
+	return value, s.entry(key, value).Exp, nil
+}
@@ -0,0 +1,158 @@
+package skhron_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/skhrontest"
+)
+
+func TestLoadingCacheDeduplicatesLoads(t *testing.T) {
+	storage := skhron.New[string]()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	cache := skhron.NewLoadingCache(storage, func(ctx context.Context, key string) (string, error) {
+		calls.Add(1)
+		<-release
+		return "value of " + key, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := cache.Get(context.Background(), "key"); err != nil || v != "value of key" {
+				t.Errorf("Get(key) = %q, %v, want \"value of key\"", v, err)
+			}
+		}()
+	}
+
+	waitFor(t, func() bool { return calls.Load() == 1 })
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("loader was called %d times, want 1", n)
+	}
+	if v, _ := storage.Get("key"); v != "value of key" {
+		t.Errorf("loaded value was not stored, got %q", v)
+	}
+}
+
+func TestLoadingCacheLeaderCancel(t *testing.T) {
+	storage := skhron.New[string]()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	cache := skhron.NewLoadingCache(storage, func(ctx context.Context, key string) (string, error) {
+		calls.Add(1)
+		select {
+		case <-release:
+			return "value of " + key, nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		_, err := cache.Get(ctx, "key")
+		leader <- err
+	}()
+	waitFor(t, func() bool { return calls.Load() == 1 })
+
+	follower := make(chan string, 1)
+	go func() {
+		v, _ := cache.Get(context.Background(), "key")
+		follower <- v
+	}()
+
+	// the first reader gives up, but the shared load goes on for the second one
+	cancel()
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("Get(key) with cancelled context = %v, want %v", err, context.Canceled)
+	}
+	close(release)
+
+	if v := <-follower; v != "value of key" {
+		t.Errorf("Get(key) = %q, want \"value of key\"", v)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("loader was called %d times, want 1", n)
+	}
+}
+
+func TestLoadingCacheRefreshAhead(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(skhron.WithClock[int](clock))
+
+	var version atomic.Int32
+	cache := skhron.NewLoadingCache(storage, func(ctx context.Context, key string) (int, error) {
+		return int(version.Add(1)), nil
+	}, skhron.WithLoadTTL(time.Minute), skhron.WithRefreshAhead(10*time.Second))
+
+	if v, _ := cache.Get(context.Background(), "key"); v != 1 {
+		t.Fatalf("Get(key) = %d, want 1", v)
+	}
+
+	clock.Advance(40 * time.Second)
+	if v, _ := cache.Get(context.Background(), "key"); v != 1 || version.Load() != 1 {
+		t.Errorf("fresh value was reloaded: %d (loads %d)", v, version.Load())
+	}
+
+	// within the refresh-ahead window the cached value is served and reloaded in background
+	clock.Advance(15 * time.Second)
+	if v, _ := cache.Get(context.Background(), "key"); v != 1 {
+		t.Errorf("Get(key) within refresh window = %d, want 1", v)
+	}
+	waitFor(t, func() bool { v, _ := storage.Get("key"); return v == 2 })
+
+	// the refreshed value is fresh for another TTL
+	clock.Advance(40 * time.Second)
+	storage.CleanUpN(0)
+	if v, err := cache.Get(context.Background(), "key"); err != nil || v != 2 || version.Load() != 2 {
+		t.Errorf("Get(key) after refresh = %d, %v (loads %d), want 2", v, err, version.Load())
+	}
+}
+
+func TestLoadingCacheStaleGrace(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(skhron.WithClock[int](clock))
+
+	var failing atomic.Bool
+	var loads atomic.Int32
+	errBackend := errors.New("backend is down")
+	cache := skhron.NewLoadingCache(storage, func(ctx context.Context, key string) (int, error) {
+		loads.Add(1)
+		if failing.Load() {
+			return 0, errBackend
+		}
+		return 1, nil
+	}, skhron.WithLoadTTL(time.Minute), skhron.WithStaleGrace(time.Minute))
+
+	cache.Get(context.Background(), "key")
+	failing.Store(true)
+
+	// stale value is served while the refresh fails
+	clock.Advance(90 * time.Second)
+	storage.CleanUpN(0)
+	if v, err := cache.Get(context.Background(), "key"); err != nil || v != 1 {
+		t.Errorf("Get(key) within grace period = %d, %v, want 1", v, err)
+	}
+	waitFor(t, func() bool { return loads.Load() == 2 })
+
+	// after the grace period the value is gone and the loader error is returned
+	clock.Advance(time.Minute)
+	storage.CleanUpN(0)
+	if _, err := cache.Get(context.Background(), "key"); !errors.Is(err, errBackend) {
+		t.Errorf("Get(key) after grace period = %v, want %v", err, errBackend)
+	}
+}
@@ -0,0 +1,141 @@
+package skhron
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidNamespace is returned (wrapped with the name) by `Namespace` when the name is empty
+// or can not be a part of a file name in the snapshot directory (it contains `/`, `\` or `..`).
+var ErrInvalidNamespace = errors.New("invalid namespace name")
+
+// namespaces is a registry of namespaces of a storage.
+type namespaces[V any] struct {
+	mu     sync.Mutex
+	byName map[string]*Skhron[V]
+}
+
+// Namespace is a function which returns a namespace (a logical database) of the storage.
+// A namespace is a separate Skhron with its own keys, limits, stats, watchers and snapshot file
+// (`{snapshot name}.{name}.skh`), so the name must be a valid part of a file name (see ErrInvalidNamespace).
+// It inherits configuration of the parent (directories, codec, clock, cleanup and persistence settings),
+// which may be overridden by `opts`. Per-namespace options (e.g. skhron.WithMaxKeys,
+// skhron.WithDefaultTTL) are not inherited.
+// The namespace is created on the first call, later calls return the same namespace and ignore `opts`.
+// The namespace has no background workers of its own: expired items are removed by the expiry scheduler
+// of the parent, and snapshots of the parent (periodic ones and the final one created by `Close`)
+// include snapshots of its namespaces.
+// If the parent is running and persistence is enabled, the snapshot of a new namespace is loaded;
+// if it fails, the namespace is not created and the error is returned.
+// Snapshots of the parent list its namespaces, so `LoadSnapshot` of the parent restores them as well.
+func (s *Skhron[V]) Namespace(name string, opts ...StorageOpt[V]) (*Skhron[V], error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidNamespace, name)
+	}
+
+	s.children.mu.Lock()
+	defer s.children.mu.Unlock()
+
+	if ns, ok := s.children.byName[name]; ok {
+		return ns, nil
+	}
+
+	ns := New[V]()
+	s.mu.RLock()
+	s.inherit(name)(ns)
+	s.mu.RUnlock()
+	for _, opt := range opts {
+		opt(ns)
+	}
+
+	if ns.Persistent && s.running() {
+		if err := ns.LoadSnapshot(); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("namespace %q: %w", name, err)
+		}
+	}
+
+	if s.children.byName == nil {
+		s.children.byName = make(map[string]*Skhron[V])
+	}
+	s.children.byName[name] = ns
+
+	return ns, nil
+}
+
+// Namespaces is a function which returns sorted names of namespaces of the storage.
+func (s *Skhron[V]) Namespaces() []string {
+	s.children.mu.Lock()
+	defer s.children.mu.Unlock()
+
+	names := make([]string, 0, len(s.children.byName))
+	for name := range s.children.byName {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// inherit is a function which creates an option copying configuration of the storage into namespace `name`.
+// The namespace shares the wake up channel of the expiry scheduler and the lifecycle of the storage.
+// The option must be applied while the lock of the storage is held (for reading at least).
+func (s *Skhron[V]) inherit(name string) StorageOpt[V] {
+	return func(ns *Skhron[V]) {
+		ns.parent = s
+		ns.wake = s.wake
+
+		ns.SnapshotDir = s.SnapshotDir
+		ns.SnapshotName = s.SnapshotName + "." + name
+		ns.TempSnapshotDir = s.TempSnapshotDir
+		ns.Codec = s.Codec
+		ns.Clock = s.Clock
+		ns.ExpiryBatch = s.ExpiryBatch
+		ns.ExpiryGranularity = s.ExpiryGranularity
+		ns.CleanupBudget = s.CleanupBudget
+		ns.CleanupRepeatRatio = s.CleanupRepeatRatio
+		ns.Persistent = s.Persistent
+
+		ns.Data.SetLimit(s.Data.GetLimit())
+		if s.ordered != nil {
+			ns.ordered = newOrderedIndex()
+		}
+	}
+}
+
+// eachNamespace is a function which calls `fn` for every namespace of the storage.
+// The registry is not locked while `fn` runs.
+func (s *Skhron[V]) eachNamespace(fn func(name string, ns *Skhron[V])) {
+	s.children.mu.Lock()
+	byName := make(map[string]*Skhron[V], len(s.children.byName))
+	for name, ns := range s.children.byName {
+		byName[name] = ns
+	}
+	s.children.mu.Unlock()
+
+	for name, ns := range byName {
+		fn(name, ns)
+	}
+}
+
+// loadNamespaces is a function which creates namespaces listed in a snapshot and loads their snapshots.
+// A missing snapshot of a namespace is not an error.
+// It must be called without holding the lock.
+func (s *Skhron[V]) loadNamespaces(names []string) error {
+	var errs []error
+
+	for _, name := range names {
+		ns, err := s.Namespace(name)
+		if err == nil {
+			err = ns.LoadSnapshot()
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("namespace %q: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
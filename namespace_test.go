@@ -0,0 +1,198 @@
+package skhron_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/skhrontest"
+)
+
+// namespace is a function which returns the namespace of the storage or fails the test.
+func namespace[V any](t *testing.T, s *skhron.Skhron[V], name string, opts ...skhron.StorageOpt[V]) *skhron.Skhron[V] {
+	t.Helper()
+
+	ns, err := s.Namespace(name, opts...)
+	if err != nil {
+		t.Fatalf("Namespace(%s) failed: %v", name, err)
+	}
+	return ns
+}
+
+func TestNamespaceIsolation(t *testing.T) {
+	storage := skhron.New[string]()
+	tenant := namespace(t, storage, "tenant", skhron.WithMaxKeys[string](2))
+
+	if namespace(t, storage, "tenant") != tenant {
+		t.Fatalf("Namespace returned a new namespace for the same name")
+	}
+
+	storage.Put("key", "parent")
+	tenant.Put("key", "tenant")
+
+	if v, _ := storage.Get("key"); v != "parent" {
+		t.Errorf("parent Get(key) = %q, want parent", v)
+	}
+	if v, _ := tenant.Get("key"); v != "tenant" {
+		t.Errorf("tenant Get(key) = %q, want tenant", v)
+	}
+
+	tenant.Put("other", "tenant")
+	if err := tenant.Put("third", "tenant"); !errors.Is(err, skhron.ErrKeyLimit) {
+		t.Errorf("Put over the limit = %v, want %v", err, skhron.ErrKeyLimit)
+	}
+	if err := tenant.Put("key", "updated"); err != nil {
+		t.Errorf("Put of existing key over the limit = %v, want nil", err)
+	}
+	if err := storage.Put("third", "parent"); err != nil {
+		t.Errorf("limit of namespace applied to parent: %v", err)
+	}
+
+	if n := tenant.FlushAll(); n != 2 {
+		t.Errorf("FlushAll() = %d, want 2", n)
+	}
+	if tenant.Len() != 0 || storage.Len() != 2 {
+		t.Errorf("Len after flush = %d (tenant), %d (parent), want 0, 2", tenant.Len(), storage.Len())
+	}
+
+	// names which could escape the snapshot directory are rejected
+	for _, name := range []string{"", "a/../../x", `a\b`, ".."} {
+		if _, err := storage.Namespace(name); !errors.Is(err, skhron.ErrInvalidNamespace) {
+			t.Errorf("Namespace(%q) = %v, want %v", name, err, skhron.ErrInvalidNamespace)
+		}
+	}
+
+	if names := storage.Namespaces(); len(names) != 1 || names[0] != "tenant" {
+		t.Errorf("Namespaces() = %v, want [tenant]", names)
+	}
+}
+
+func TestNamespaceStats(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(skhron.WithClock[int](clock))
+	tenant := namespace(t, storage, "tenant", skhron.WithDefaultTTL[int](time.Minute))
+
+	tenant.Put("expiring", 1)
+	tenant.Put("deleted", 2)
+	tenant.Put("flushed", 3)
+	tenant.Get("deleted")
+	tenant.Get("missing")
+	tenant.Delete("deleted")
+
+	clock.Advance(30 * time.Second)
+	tenant.Put("flushed", 4) // default TTL is renewed by Put
+	clock.Advance(45 * time.Second)
+	tenant.CleanUpN(0)
+	tenant.FlushAll()
+
+	want := skhron.Stats{Keys: 0, Hits: 1, Misses: 1, Puts: 4, Deletes: 1, Expired: 1, Evicted: 1}
+	if got := tenant.Stats(); got != want {
+		t.Errorf("tenant Stats() = %+v, want %+v", got, want)
+	}
+
+	if got := storage.Stats(); got != (skhron.Stats{}) {
+		t.Errorf("parent Stats() = %+v, want zero", got)
+	}
+}
+
+func TestNamespaceSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	opts := []skhron.StorageOpt[string]{
+		skhron.WithSnapshotDir[string](dir),
+		skhron.WithTempSnapshotDir[string](dir),
+	}
+
+	storage, err := skhron.Open(opts...)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	storage.Put("key", "parent")
+	namespace(t, storage, "a").Put("key", "a")
+	namespace(t, storage, "b").PutTTL("key", "b", time.Hour)
+
+	if err := storage.Close(context.Background()); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	for _, name := range []string{"snapshot", "snapshot.a", "snapshot.b"} {
+		if _, err := os.Stat(path.Join(dir, name+skhron.SkhronExtension)); err != nil {
+			t.Errorf("snapshot %s was not created: %v", name, err)
+		}
+	}
+
+	reopened, err := skhron.Open(opts...)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close(context.Background())
+
+	if names := reopened.Namespaces(); len(names) != 2 {
+		t.Fatalf("Namespaces() = %v, want [a b]", names)
+	}
+
+	for _, c := range []struct {
+		storage *skhron.Skhron[string]
+		want    string
+	}{
+		{reopened, "parent"},
+		{namespace(t, reopened, "a"), "a"},
+		{namespace(t, reopened, "b"), "b"},
+	} {
+		if v, err := c.storage.Get("key"); err != nil || v != c.want {
+			t.Errorf("Get(key) = %q, %v, want %q", v, err, c.want)
+		}
+	}
+}
+
+func TestNamespaceSharedWorkers(t *testing.T) {
+	dir := t.TempDir()
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts := []skhron.StorageOpt[string]{
+		skhron.WithClock[string](clock),
+		skhron.WithSnapshotDir[string](dir),
+		skhron.WithTempSnapshotDir[string](dir),
+		skhron.WithExpiryGranularity[string](0),
+	}
+
+	storage, err := skhron.Open(opts...)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	// expired keys of a namespace are removed by the expiry scheduler of the parent
+	tenant := namespace(t, storage, "tenant")
+	tenant.PutTTL("key", "tenant", time.Second)
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Second)
+	waitFor(t, func() bool { return tenant.Len() == 0 })
+
+	if err := tenant.Close(context.Background()); !errors.Is(err, skhron.ErrClosed) {
+		t.Errorf("Close of namespace = %v, want %v", err, skhron.ErrClosed)
+	}
+	if err := storage.Close(context.Background()); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	// a corrupt snapshot of a namespace is reported instead of creating an empty namespace
+	if err := os.WriteFile(path.Join(dir, "snapshot.broken"+skhron.SkhronExtension), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := skhron.Open(opts...)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close(context.Background())
+
+	if ns, err := reopened.Namespace("broken"); err == nil || ns != nil {
+		t.Errorf("Namespace(broken) = %v, %v, want error", ns, err)
+	}
+	if names := reopened.Namespaces(); len(names) != 1 || names[0] != "tenant" {
+		t.Errorf("Namespaces() = %v, want [tenant]", names)
+	}
+}
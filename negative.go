@@ -0,0 +1,51 @@
+package skhron
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNegativeCached is returned (wrapped with the key) when the key is known to be missing (see `PutNegative`).
+var ErrNegativeCached = errors.New("negative cached")
+
+// PutNegative is a function which records that the key is missing (e.g. in the backend of a cache)
+// for `ttl`, so lookups of the key fail with ErrNegativeCached instead of ErrNoSuchKey.
+// A value under the key is deleted: watchers are notified with EventNegative, it is not counted in `Deletes`.
+// Putting a value under the key removes the negative entry.
+// Negative entries are never visible to iteration, `Exists` and `Len`, and they are never persisted in snapshots.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) PutNegative(key string, ttl time.Duration) error {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(key, EventNegative)
+	s.negatives[key] = struct{}{}
+	s.schedule(key, s.Clock.Now().Add(ttl))
+
+	return nil
+}
+
+// missing is a function which counts a lookup of the key and, if the key is not `found`,
+// returns the error describing why: ErrWrongType, ErrNegativeCached or ErrNoSuchKey.
+// The caller must hold the lock (for reading at least).
+func (s *Skhron[V]) missing(key string, found bool) error {
+	if found {
+		s.stats.hits.Add(1)
+		return nil
+	}
+
+	if _, ok := s.colls[key]; ok {
+		s.stats.misses.Add(1)
+		return wrongType(key)
+	}
+
+	if _, ok := s.negatives[key]; ok {
+		s.stats.negativeHits.Add(1)
+		return fmt.Errorf("%w: %s", ErrNegativeCached, key)
+	}
+
+	s.stats.misses.Add(1)
+	return noSuchKey(key)
+}
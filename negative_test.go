@@ -0,0 +1,112 @@
+package skhron_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/skhrontest"
+)
+
+func TestNegativeEntries(t *testing.T) {
+	dir := t.TempDir()
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts := []skhron.StorageOpt[int]{
+		skhron.WithClock[int](clock),
+		skhron.WithSnapshotDir[int](dir),
+		skhron.WithTempSnapshotDir[int](dir),
+	}
+	storage := skhron.New(opts...)
+
+	storage.Put("present", 1)
+	storage.Put("replaced", 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := storage.Watch(ctx, nil)
+
+	storage.PutNegative("absent", time.Minute)
+	storage.PutNegative("replaced", time.Minute)
+
+	// replacing a value is reported, but it is not a delete
+	select {
+	case e := <-events:
+		if e.Type != skhron.EventNegative || e.Key != "replaced" || e.Value != 2 {
+			t.Errorf("event = %+v, want negative of \"replaced\"", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no event received")
+	}
+
+	for _, key := range []string{"absent", "replaced"} {
+		if _, err := storage.Get(key); !errors.Is(err, skhron.ErrNegativeCached) {
+			t.Errorf("Get(%s) = %v, want %v", key, err, skhron.ErrNegativeCached)
+		}
+	}
+	if _, err := storage.Get("unknown"); !errors.Is(err, skhron.ErrNoSuchKey) {
+		t.Errorf("Get(unknown) = %v, want %v", err, skhron.ErrNoSuchKey)
+	}
+
+	for key := range storage.All() {
+		if key != "present" {
+			t.Errorf("negative entry %q is visible to iteration", key)
+		}
+	}
+
+	want := skhron.Stats{Keys: 1, Negatives: 2, Misses: 1, NegativeHits: 2, Puts: 2}
+	if got := storage.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+
+	// negative entries are not persisted
+	if err := storage.CreateSnapshot(); err != nil {
+		t.Fatalf("create snapshot failed: %v", err)
+	}
+	loaded := skhron.New(opts...)
+	if err := loaded.LoadSnapshot(); err != nil {
+		t.Fatalf("load snapshot failed: %v", err)
+	}
+	if _, err := loaded.Get("absent"); !errors.Is(err, skhron.ErrNoSuchKey) {
+		t.Errorf("Get(absent) after snapshot = %v, want %v", err, skhron.ErrNoSuchKey)
+	}
+
+	// a put value replaces the negative entry and does not inherit its TTL
+	storage.Put("absent", 3)
+	clock.Advance(2 * time.Minute)
+	storage.CleanUpN(0)
+	if v, err := storage.Get("absent"); err != nil || v != 3 {
+		t.Errorf("Get(absent) = %d, %v, want 3", v, err)
+	}
+	if _, err := storage.Get("replaced"); !errors.Is(err, skhron.ErrNoSuchKey) {
+		t.Errorf("Get(replaced) after expiry = %v, want %v", err, skhron.ErrNoSuchKey)
+	}
+}
+
+func TestLoadingCacheNegative(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(skhron.WithClock[int](clock))
+
+	loads := 0
+	cache := skhron.NewLoadingCache(storage, func(ctx context.Context, key string) (int, error) {
+		loads++
+		return 0, fmt.Errorf("user %s: %w", key, skhron.ErrNoSuchKey)
+	}, skhron.WithNegativeTTL(10*time.Second))
+
+	if _, err := cache.Get(context.Background(), "bob"); !errors.Is(err, skhron.ErrNoSuchKey) {
+		t.Errorf("first Get(bob) = %v, want %v", err, skhron.ErrNoSuchKey)
+	}
+	if _, err := cache.Get(context.Background(), "bob"); !errors.Is(err, skhron.ErrNegativeCached) {
+		t.Errorf("second Get(bob) = %v, want %v", err, skhron.ErrNegativeCached)
+	}
+	if loads != 1 {
+		t.Errorf("loader was called %d times, want 1", loads)
+	}
+
+	clock.Advance(11 * time.Second)
+	cache.Get(context.Background(), "bob")
+	if loads != 2 {
+		t.Errorf("loader was not called after negative entry expired")
+	}
+}
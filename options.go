@@ -1,5 +1,7 @@
 package skhron
 
+import "time"
+
 var (
 	SkhronExtension = ".skh"
 )
@@ -10,6 +12,14 @@ func DefaultOpts[V any](s *Skhron[V]) {
 	s.SnapshotDir = ".skhron"
 	s.SnapshotName = "snapshot"
 	s.TempSnapshotDir = "/tmp/skhron"
+	s.Codec = JSONCodec[V]{}
+	s.Clock = SystemClock{}
+	s.ExpiryBatch = 1000
+	s.ExpiryGranularity = 10 * time.Millisecond
+	s.CleanupBudget = 25 * time.Millisecond
+	s.CleanupRepeatRatio = 0.25
+	s.Persistent = true
+	s.rng = newRand(0)
 
 	s.Data.SetLimit(10000) // shrink map after every 10k deletions
 }
@@ -37,3 +47,87 @@ func WithMapLimit[V any](limit uint64) StorageOpt[V] {
 		s.Data.SetLimit(limit)
 	}
 }
+
+func WithValueCodec[V any](codec ValueCodec[V]) StorageOpt[V] {
+	return func(s *Skhron[V]) {
+		s.Codec = codec
+	}
+}
+
+func WithClock[V any](clock Clock) StorageOpt[V] {
+	return func(s *Skhron[V]) {
+		s.Clock = clock
+	}
+}
+
+func WithExpiryBatch[V any](batch int) StorageOpt[V] {
+	return func(s *Skhron[V]) {
+		s.ExpiryBatch = batch
+	}
+}
+
+func WithExpiryGranularity[V any](granularity time.Duration) StorageOpt[V] {
+	return func(s *Skhron[V]) {
+		s.ExpiryGranularity = granularity
+	}
+}
+
+func WithCleanupBudget[V any](budget time.Duration) StorageOpt[V] {
+	return func(s *Skhron[V]) {
+		s.CleanupBudget = budget
+	}
+}
+
+func WithCleanupRepeatRatio[V any](ratio float64) StorageOpt[V] {
+	return func(s *Skhron[V]) {
+		s.CleanupRepeatRatio = ratio
+	}
+}
+
+func WithPersistence[V any](enabled bool) StorageOpt[V] {
+	return func(s *Skhron[V]) {
+		s.Persistent = enabled
+	}
+}
+
+func WithSnapshotInterval[V any](interval time.Duration) StorageOpt[V] {
+	return func(s *Skhron[V]) {
+		s.SnapshotInterval = interval
+	}
+}
+
+func WithOrderedIndex[V any]() StorageOpt[V] {
+	return func(s *Skhron[V]) {
+		s.ordered = newOrderedIndex()
+	}
+}
+
+func WithMaxKeys[V any](limit int) StorageOpt[V] {
+	return func(s *Skhron[V]) {
+		s.MaxKeys = limit
+	}
+}
+
+func WithDefaultTTL[V any](ttl time.Duration) StorageOpt[V] {
+	return func(s *Skhron[V]) {
+		s.DefaultTTL = ttl
+	}
+}
+
+func WithSlidingExpiration[V any]() StorageOpt[V] {
+	return func(s *Skhron[V]) {
+		s.SlidingExpiration = true
+	}
+}
+
+func WithTTLJitter[V any](fraction float64) StorageOpt[V] {
+	return func(s *Skhron[V]) {
+		s.TTLJitter = fraction
+	}
+}
+
+func WithJitterSeed[V any](seed uint64) StorageOpt[V] {
+	return func(s *Skhron[V]) {
+		s.rng = newRand(seed)
+	}
+}
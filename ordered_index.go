@@ -0,0 +1,161 @@
+package skhron
+
+import (
+	"math/rand/v2"
+)
+
+const (
+	skipMaxLevel = 32 // enough for 4^32 keys
+	skipP        = 4  // each level holds 1/skipP of the nodes of the level below
+)
+
+// orderedIndex is a skiplist of keys, which is maintained alongside Skhron.Data
+// when skhron.WithOrderedIndex option is used.
+// It is not safe for concurrent use, Skhron mutex protects it.
+type orderedIndex struct {
+	head  *skipNode
+	level int
+	len   int
+}
+
+type skipNode struct {
+	key  string
+	prev *skipNode // previous node on the lowest level, nil for the first node
+	next []*skipNode
+}
+
+func newOrderedIndex() *orderedIndex {
+	x := &orderedIndex{}
+	x.reset()
+	return x
+}
+
+// reset is a function which removes all keys from the index in place,
+// so readers holding the pointer to the index keep using the same one.
+func (x *orderedIndex) reset() {
+	x.head = &skipNode{next: make([]*skipNode, skipMaxLevel)}
+	x.level = 1
+	x.len = 0
+}
+
+func randomLevel() int {
+	level := 1
+	for level < skipMaxLevel && rand.IntN(skipP) == 0 {
+		level++
+	}
+	return level
+}
+
+// findPrev is a function which fills `update` with the last node before `key` on each level.
+// It returns the first node with key greater or equal to `key`.
+func (x *orderedIndex) findPrev(key string, update []*skipNode) *skipNode {
+	node := x.head
+	for i := x.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && node.next[i].key < key {
+			node = node.next[i]
+		}
+		if update != nil {
+			update[i] = node
+		}
+	}
+	return node.next[0]
+}
+
+// insert is a function which adds the key into the index, if it is not present.
+func (x *orderedIndex) insert(key string) {
+	update := make([]*skipNode, skipMaxLevel)
+	if node := x.findPrev(key, update); node != nil && node.key == key {
+		return
+	}
+
+	level := randomLevel()
+	if level > x.level {
+		for i := x.level; i < level; i++ {
+			update[i] = x.head
+		}
+		x.level = level
+	}
+
+	node := &skipNode{key: key, next: make([]*skipNode, level)}
+	for i := 0; i < level; i++ {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+
+	if update[0] != x.head {
+		node.prev = update[0]
+	}
+	if node.next[0] != nil {
+		node.next[0].prev = node
+	}
+
+	x.len++
+}
+
+// delete is a function which removes the key from the index, if it is present.
+func (x *orderedIndex) delete(key string) {
+	update := make([]*skipNode, skipMaxLevel)
+	node := x.findPrev(key, update)
+	if node == nil || node.key != key {
+		return
+	}
+
+	for i := 0; i < len(node.next); i++ {
+		update[i].next[i] = node.next[i]
+	}
+	if node.next[0] != nil {
+		node.next[0].prev = node.prev
+	}
+
+	for x.level > 1 && x.head.next[x.level-1] == nil {
+		x.level--
+	}
+
+	x.len--
+}
+
+// ascend is a function which returns at most `limit` keys from range [from, to) in ascending order.
+// Empty `to` means the range is not bounded from above.
+func (x *orderedIndex) ascend(from, to string, limit int) []string {
+	keys := make([]string, 0, min(limit, x.len))
+	for node := x.findPrev(from, nil); node != nil && len(keys) < limit; node = node.next[0] {
+		if to != "" && node.key >= to {
+			break
+		}
+		keys = append(keys, node.key)
+	}
+	return keys
+}
+
+// descend is a function which returns at most `limit` keys from range [from, to) in descending order.
+// Empty `to` means the range is not bounded from above.
+func (x *orderedIndex) descend(from, to string, limit int) []string {
+	var node *skipNode
+	if to == "" {
+		node = x.last()
+	} else if next := x.findPrev(to, nil); next != nil {
+		node = next.prev
+	} else {
+		node = x.last()
+	}
+
+	keys := make([]string, 0, min(limit, x.len))
+	for ; node != nil && node.key >= from && len(keys) < limit; node = node.prev {
+		keys = append(keys, node.key)
+	}
+	return keys
+}
+
+// last is a function which returns the node with the greatest key, or nil if the index is empty.
+func (x *orderedIndex) last() *skipNode {
+	node := x.head
+	for i := x.level - 1; i >= 0; i-- {
+		for node.next[i] != nil {
+			node = node.next[i]
+		}
+	}
+	if node == x.head {
+		return nil
+	}
+	return node
+}
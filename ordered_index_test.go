@@ -0,0 +1,131 @@
+package skhron
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOrderedIndex(t *testing.T) {
+	x := newOrderedIndex()
+	present := make(map[string]bool)
+
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("key%04d", rand.IntN(1000))
+		if rand.IntN(3) == 0 {
+			x.delete(key)
+			delete(present, key)
+		} else {
+			x.insert(key)
+			present[key] = true
+		}
+	}
+
+	want := make([]string, 0, len(present))
+	for key := range present {
+		want = append(want, key)
+	}
+	slices.Sort(want)
+
+	if x.len != len(want) {
+		t.Errorf("len = %d, want %d", x.len, len(want))
+	}
+
+	if got := x.ascend("", "", len(want)+1); !reflect.DeepEqual(got, want) {
+		t.Errorf("ascend() = %v, want %v", got, want)
+	}
+
+	slices.Reverse(want)
+	if got := x.descend("", "", len(want)+1); !reflect.DeepEqual(got, want) {
+		t.Errorf("descend() = %v, want %v", got, want)
+	}
+}
+
+func TestRange(t *testing.T) {
+	configs := map[string][]StorageOpt[int]{
+		"without index": nil,
+		"with index":    {WithOrderedIndex[int]()},
+	}
+
+	for name, opts := range configs {
+		t.Run(name, func(t *testing.T) {
+			s := New(opts...)
+
+			keys := make([]string, 0, 2*iterChunk)
+			for i := 0; i < 2*iterChunk; i++ {
+				key := fmt.Sprintf("k%04d", i)
+				keys = append(keys, key)
+				s.Put(key, i)
+			}
+			s.Put("a", -1)
+			s.Put("z", -1)
+			s.PutTTL("k0001", 1, -time.Second) // expired
+			s.Delete("k0002")
+
+			want := slices.Concat(keys[:1], keys[3:])
+
+			collect := func(seq func(func(string, int) bool)) []string {
+				got := []string{}
+				for key := range seq {
+					got = append(got, key)
+				}
+				return got
+			}
+
+			if got := collect(s.Range("k", "l")); !reflect.DeepEqual(got, want) {
+				t.Errorf("Range() = %v, want %v", got, want)
+			}
+
+			// without index the order of ScanPrefix is not specified
+			if got := collect(s.ScanPrefix("k")); !reflect.DeepEqual(slices.Sorted(slices.Values(got)), want) {
+				t.Errorf("ScanPrefix() = %v, want %v", got, want)
+			}
+
+			reversed := slices.Clone(want)
+			slices.Reverse(reversed)
+			if got := collect(s.RangeReverse("k", "l")); !reflect.DeepEqual(got, reversed) {
+				t.Errorf("RangeReverse() = %v, want %v", got, reversed)
+			}
+
+			if got := collect(s.Range("", "")); len(got) != len(want)+2 || got[0] != "a" || got[len(got)-1] != "z" {
+				t.Errorf("Range(\"\", \"\") = %v", got)
+			}
+
+			paged := []string{}
+			for cursor := "k"; ; {
+				page, next := s.Page(cursor, 100)
+				for _, e := range page {
+					paged = append(paged, e.Key)
+				}
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+			if want := append(slices.Clone(want), "z"); !reflect.DeepEqual(paged, want) {
+				t.Errorf("Page() = %v, want %v", paged, want)
+			}
+		})
+	}
+}
+
+func TestPrefixEnd(t *testing.T) {
+	tests := []struct {
+		prefix string
+		end    string
+	}{
+		{prefix: "abc", end: "abd"},
+		{prefix: "ab\xff", end: "ac"},
+		{prefix: "\xff\xff", end: ""},
+		{prefix: "", end: ""},
+	}
+
+	for _, tt := range tests {
+		if got := prefixEnd(tt.prefix); got != tt.end {
+			t.Errorf("prefixEnd(%q) = %q, want %q", tt.prefix, got, tt.end)
+		}
+	}
+}
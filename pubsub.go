@@ -0,0 +1,148 @@
+package skhron
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// RetainedKeyPrefix is a prefix of keys which store retained messages of channels
+// (see Skhron.PublishRetained).
+const RetainedKeyPrefix = "pubsub:retained:"
+
+// Message is a message published to a channel.
+type Message[V any] struct {
+	Channel string
+	Payload V
+	// Whether the message is the retained message of the channel delivered on subscription
+	Retained bool
+}
+
+// Publish is a function which sends the message to subscribers of the channel.
+// Subscribers receive messages in the order they were published.
+// Each subscriber has a bounded buffer and an overflow policy, like in Watch.
+// It returns the number of subscribers the message is delivered to.
+func (s *Skhron[V]) Publish(channel string, msg V) int {
+	defer s.messages.dispatch()
+
+	m := Message[V]{Channel: channel, Payload: msg}
+	n := s.messages.receivers(m)
+	s.messages.publish(m)
+
+	return n
+}
+
+// PublishRetained is a function which sends the message to subscribers of the channel
+// and stores it as the retained message of the channel under the key `RetainedKeyPrefix + channel`.
+// The retained message is delivered to new subscribers of the channel first.
+// If `ttl` is positive, the retained message expires after `ttl`, otherwise it is kept until replaced.
+// If the storage is full (skhron.WithMaxKeys option), the message is sent but not retained.
+// It returns the number of subscribers the message is delivered to.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) PublishRetained(channel string, msg V, ttl time.Duration) int {
+	defer s.messages.dispatch()
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := RetainedKeyPrefix + channel
+	if s.admit(key) == nil {
+		s.set(key, msg)
+		if ttl > 0 {
+			s.schedule(key, s.Clock.Now().Add(ttl))
+		} else {
+			s.unschedule(key)
+		}
+	}
+
+	m := Message[V]{Channel: channel, Payload: msg}
+	n := s.messages.receivers(m)
+	s.messages.publish(m)
+
+	return n
+}
+
+// Subscribe is a function which subscribes to messages of the channels.
+// The channel is closed when `ctx` is done.
+func (s *Skhron[V]) Subscribe(ctx context.Context, channels ...string) <-chan Message[V] {
+	names := make(map[string]struct{}, len(channels))
+	for _, channel := range channels {
+		names[channel] = struct{}{}
+	}
+
+	return s.SubscribeMatch(ctx, func(channel string) bool {
+		_, ok := names[channel]
+		return ok
+	})
+}
+
+// PSubscribe is a function which subscribes to messages of the channels
+// matching any of the glob patterns (see Skhron.FindGlob for the syntax).
+// The channel is closed when `ctx` is done.
+func (s *Skhron[V]) PSubscribe(ctx context.Context, patterns ...string) <-chan Message[V] {
+	return s.SubscribeMatch(ctx, func(channel string) bool {
+		for _, pattern := range patterns {
+			if matchGlob(pattern, channel) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// SubscribeMatch is a function which subscribes to messages of the channels selected by the filter
+// (nil filter selects all channels). Retained messages of the selected channels are delivered first.
+// Buffer size and overflow policy are set with skhron.WithBuffer and skhron.WithOverflow options.
+// Retained messages are subject to them like other messages: they are sent before the channel is returned,
+// so with OverflowDrop policy retained messages which do not fit into the buffer are dropped
+// (with zero buffer all of them), OverflowQueue policy keeps them until the subscriber reads.
+// The channel is closed when `ctx` is done or the subscriber is disconnected.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) SubscribeMatch(ctx context.Context, filter KeyFilter, opts ...SubscribeOpt) <-chan Message[V] {
+	if filter == nil {
+		filter = func(string) bool { return true }
+	}
+
+	sub := newSubscriber(ctx, func(m Message[V]) bool { return filter(m.Channel) }, opts)
+
+	// hold the delivery lock, so no message is delivered before retained ones
+	s.messages.dmu.Lock()
+	defer s.messages.dmu.Unlock()
+
+	// register under the storage lock, so each retained message is either read here or delivered later
+	s.mu.RLock()
+	s.messages.add(sub)
+	retained := s.retained(filter)
+	s.mu.RUnlock()
+
+	for _, m := range retained {
+		s.messages.deliver(sub, m)
+	}
+
+	return sub.ch
+}
+
+// retained is a function which returns retained messages of the channels selected by the filter.
+// The caller must hold the lock.
+func (s *Skhron[V]) retained(filter KeyFilter) []Message[V] {
+	var keys []string
+	if s.ordered != nil {
+		keys = s.ordered.ascend(RetainedKeyPrefix, prefixEnd(RetainedKeyPrefix), s.ordered.len)
+	} else {
+		keys = s.sortedKeys(RetainedKeyPrefix, prefixEnd(RetainedKeyPrefix))
+	}
+
+	now := s.Clock.Now()
+	messages := make([]Message[V], 0)
+	for _, key := range keys {
+		channel := strings.TrimPrefix(key, RetainedKeyPrefix)
+		if !filter(channel) || s.expired(key, now) {
+			continue
+		}
+		if value, ok := s.Data.Get2(key); ok {
+			messages = append(messages, Message[V]{Channel: channel, Payload: value, Retained: true})
+		}
+	}
+
+	return messages
+}
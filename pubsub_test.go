@@ -0,0 +1,85 @@
+package skhron
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// receiveMessage is a function which reads a message or fails the test after a second.
+func receiveMessage[V any](t *testing.T, ch <-chan Message[V]) Message[V] {
+	t.Helper()
+
+	select {
+	case m, ok := <-ch:
+		if !ok {
+			t.Fatalf("channel is closed")
+		}
+		return m
+	case <-time.After(time.Second):
+		t.Fatalf("no message received")
+	}
+
+	return Message[V]{}
+}
+
+func TestPubSub(t *testing.T) {
+	s := New[string]()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	news := s.Subscribe(ctx, "news", "sport")
+	all := s.PSubscribe(ctx, "news*")
+
+	if n := s.Publish("news", "hello"); n != 2 {
+		t.Errorf("Publish() = %d, want 2", n)
+	}
+	if n := s.Publish("weather", "sunny"); n != 0 {
+		t.Errorf("Publish() = %d, want 0", n)
+	}
+	s.Publish("news:local", "local")
+	s.Publish("sport", "goal")
+
+	for _, want := range []Message[string]{{Channel: "news", Payload: "hello"}, {Channel: "sport", Payload: "goal"}} {
+		if m := receiveMessage(t, news); m != want {
+			t.Errorf("message = %+v, want %+v", m, want)
+		}
+	}
+
+	for _, want := range []Message[string]{{Channel: "news", Payload: "hello"}, {Channel: "news:local", Payload: "local"}} {
+		if m := receiveMessage(t, all); m != want {
+			t.Errorf("message = %+v, want %+v", m, want)
+		}
+	}
+}
+
+func TestPubSubRetained(t *testing.T) {
+	s := New[string]()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s.PublishRetained("status", "starting", 0)
+	s.PublishRetained("status", "ready", time.Hour)
+	s.PutTTL(RetainedKeyPrefix+"expired", "old", -time.Second) // expired, but not cleaned up yet
+
+	if v, err := s.Get(RetainedKeyPrefix + "status"); err != nil || v != "ready" {
+		t.Errorf("retained message is not stored: %v, %v", v, err)
+	}
+
+	messages := s.PSubscribe(ctx, "*")
+
+	if m := receiveMessage(t, messages); m != (Message[string]{Channel: "status", Payload: "ready", Retained: true}) {
+		t.Errorf("retained message = %+v", m)
+	}
+
+	s.Publish("status", "stopping")
+	if m := receiveMessage(t, messages); m != (Message[string]{Channel: "status", Payload: "stopping"}) {
+		t.Errorf("message = %+v", m)
+	}
+
+	// with zero buffer the retained message is only kept by OverflowQueue policy
+	unbuffered := s.SubscribeMatch(ctx, nil, WithBuffer(0), WithOverflow(OverflowQueue))
+	if m := receiveMessage(t, unbuffered); m != (Message[string]{Channel: "status", Payload: "ready", Retained: true}) {
+		t.Errorf("retained message with zero buffer = %+v", m)
+	}
+}
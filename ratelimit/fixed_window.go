@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/dartt0n/skhron"
+)
+
+// FixedWindow is a limiter which allows `limit` requests per key in every window of time
+// (windows are aligned to multiples of the window duration).
+// It is cheap, but allows bursts of up to 2*limit requests around window boundaries.
+type FixedWindow struct {
+	store   *skhron.Skhron[int64]
+	counter *skhron.Counter
+	limit   int
+	window  time.Duration
+}
+
+// NewFixedWindow is a function which creates a fixed window limiter storing counters in `store`
+// under keys `{key}@{window start}`. Each counter expires with its window.
+// If `limit` or `window` is not positive, ErrInvalidConfig is returned.
+func NewFixedWindow(store *skhron.Skhron[int64], limit int, window time.Duration) (*FixedWindow, error) {
+	if err := validateWindow(limit, window); err != nil {
+		return nil, err
+	}
+
+	return &FixedWindow{
+		store:   store,
+		counter: skhron.NewCounter(store),
+		limit:   limit,
+		window:  window,
+	}, nil
+}
+
+// Allow is a function which registers a request of the key (see ratelimit.Allow).
+func (l *FixedWindow) Allow(key string) (bool, time.Duration) {
+	return Allow(l, key)
+}
+
+// Take is a function which registers a request of the key.
+// If the counter can not be stored (e.g. the storage is full), the request is rejected.
+func (l *FixedWindow) Take(key string) Result {
+	now := l.store.Clock.Now()
+	start := now.Truncate(l.window)
+	reset := start.Add(l.window).Sub(now)
+
+	n, err := l.counter.IncrBy(key+"@"+strconv.FormatInt(start.UnixNano(), 10), 1, reset)
+	if err != nil {
+		return deny(l.limit, reset)
+	}
+
+	r := Result{
+		Allowed:   n <= int64(l.limit),
+		Limit:     l.limit,
+		Remaining: max(l.limit-int(n), 0),
+		Reset:     reset,
+	}
+	if !r.Allowed {
+		r.RetryAfter = reset
+	}
+
+	return r
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// KeyFunc is a function which groups requests by a key (e.g. client address or API token).
+type KeyFunc func(r *http.Request) string
+
+// RemoteAddr is a key function which groups requests by the client IP address.
+func RemoteAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Middleware is a function which creates `net/http` middleware limiting requests with the limiter.
+// Requests are grouped by `key` (ratelimit.RemoteAddr if it is nil).
+// Every response carries `RateLimit-Limit`, `RateLimit-Remaining` and `RateLimit-Reset` headers,
+// rejected requests are answered with 429 Too Many Requests and `Retry-After` header.
+func Middleware(l Limiter, key KeyFunc) func(http.Handler) http.Handler {
+	if key == nil {
+		key = RemoteAddr
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res := l.Take(key(r))
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", seconds(res.Reset))
+
+			if !res.Allowed {
+				h.Set("Retry-After", seconds(res.RetryAfter))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// seconds is a function which formats the duration as whole seconds, rounded up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,52 @@
+// Package ratelimit provides rate limiters, which keep their state in Skhron.
+// The state of every client expires after it is no longer needed, so idle clients
+// are removed by the storage cleanup.
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidConfig is returned (wrapped with the reason) by constructors of limiters
+// when the limit, the window, the rate or the burst is not positive.
+var ErrInvalidConfig = errors.New("invalid limiter configuration")
+
+// Result is an outcome of a single request to a limiter.
+type Result struct {
+	Allowed    bool
+	Limit      int           // maximum number of requests in a window (or the burst of a token bucket)
+	Remaining  int           // number of requests left
+	Reset      time.Duration // time until the limit is fully restored
+	RetryAfter time.Duration // time until the next request may be allowed, zero if it is allowed
+}
+
+// Limiter is a rate limiter of requests grouped by keys (e.g. client addresses).
+type Limiter interface {
+	// Take registers a request of the key and reports whether it is allowed.
+	Take(key string) Result
+}
+
+// Allow is a function which registers a request of the key and reports whether it is allowed
+// and, if it is not, how long to wait before retrying.
+func Allow(l Limiter, key string) (bool, time.Duration) {
+	r := l.Take(key)
+	return r.Allowed, r.RetryAfter
+}
+
+// deny is a function which creates a result of a request rejected because the state could not be stored.
+func deny(limit int, retryAfter time.Duration) Result {
+	return Result{Allowed: false, Limit: limit, Reset: retryAfter, RetryAfter: retryAfter}
+}
+
+// validateWindow is a function which checks the limit and the window of a window limiter.
+func validateWindow(limit int, window time.Duration) error {
+	if limit <= 0 {
+		return fmt.Errorf("%w: limit %d is not positive", ErrInvalidConfig, limit)
+	}
+	if window <= 0 {
+		return fmt.Errorf("%w: window %v is not positive", ErrInvalidConfig, window)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package ratelimit_test
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/ratelimit"
+	"github.com/dartt0n/skhron/skhrontest"
+)
+
+func newClock() *skhrontest.FakeClock {
+	return skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func TestFixedWindow(t *testing.T) {
+	clock := newClock()
+	store := skhron.New(skhron.WithClock[int64](clock))
+	limiter, err := ratelimit.NewFixedWindow(store, 3, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(15 * time.Second)
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.Allow("client"); !ok {
+			t.Fatalf("request %d was rejected", i)
+		}
+	}
+
+	ok, retryAfter := limiter.Allow("client")
+	if ok || retryAfter != 45*time.Second {
+		t.Errorf("Allow over the limit = %v, %v, want false, 45s", ok, retryAfter)
+	}
+	if ok, _ := limiter.Allow("other"); !ok {
+		t.Errorf("request of another client was rejected")
+	}
+
+	// the next window starts over and the old counter expires
+	clock.Advance(45 * time.Second)
+	if ok, _ := limiter.Allow("client"); !ok {
+		t.Errorf("request in the next window was rejected")
+	}
+	clock.Advance(time.Second)
+	store.CleanUpN(0)
+	if n := store.Len(); n != 1 {
+		t.Errorf("expected counters of the previous window to expire, %d keys left", n)
+	}
+}
+
+func TestSlidingLog(t *testing.T) {
+	clock := newClock()
+	store := skhron.New(skhron.WithClock[[]time.Time](clock))
+	limiter, err := ratelimit.NewSlidingLog(store, 2, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limiter.Allow("client")
+	clock.Advance(40 * time.Second)
+	limiter.Allow("client")
+
+	ok, retryAfter := limiter.Allow("client")
+	if ok || retryAfter != 20*time.Second {
+		t.Errorf("Allow over the limit = %v, %v, want false, 20s", ok, retryAfter)
+	}
+
+	// the first request leaves the window, unlike with a fixed window the second one still counts
+	read, _ := store.Get("client")
+	first := read[0]
+	clock.Advance(20 * time.Second)
+	if r := limiter.Take("client"); !r.Allowed || r.Remaining != 0 {
+		t.Errorf("Take after the first request left the window = %+v, want allowed with 0 remaining", r)
+	}
+	if !read[0].Equal(first) {
+		t.Errorf("log read before Take was modified")
+	}
+
+	clock.Advance(2 * time.Minute)
+	store.CleanUpN(0)
+	if store.Len() != 0 {
+		t.Errorf("idle log did not expire")
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock := newClock()
+	store := skhron.New(skhron.WithClock[ratelimit.Bucket](clock))
+	limiter, err := ratelimit.NewTokenBucket(store, 2, 4) // 2 tokens per second, burst of 4
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if ok, _ := limiter.Allow("client"); !ok {
+			t.Fatalf("request %d of the burst was rejected", i)
+		}
+	}
+
+	ok, retryAfter := limiter.Allow("client")
+	if ok || retryAfter != 500*time.Millisecond {
+		t.Errorf("Allow with empty bucket = %v, %v, want false, 500ms", ok, retryAfter)
+	}
+
+	clock.Advance(time.Second)
+	if r := limiter.Take("client"); !r.Allowed || r.Remaining != 1 {
+		t.Errorf("Take after refill = %+v, want allowed with 1 remaining", r)
+	}
+
+	// the bucket expires when it is full again
+	clock.Advance(2 * time.Second)
+	store.CleanUpN(0)
+	if store.Len() != 0 {
+		t.Errorf("full bucket did not expire")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	clock := newClock()
+	store := skhron.New(skhron.WithClock[int64](clock))
+	limiter, err := ratelimit.NewFixedWindow(store, 1, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := ratelimit.Middleware(limiter, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := serve()
+	if first.Code != http.StatusNoContent {
+		t.Fatalf("first request status = %d, want %d", first.Code, http.StatusNoContent)
+	}
+	for header, want := range map[string]string{"RateLimit-Limit": "1", "RateLimit-Remaining": "0", "RateLimit-Reset": "60"} {
+		if got := first.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	second := serve()
+	if second.Code != http.StatusTooManyRequests || second.Header().Get("Retry-After") != "60" {
+		t.Errorf("second request = %d (Retry-After %q), want %d (60)",
+			second.Code, second.Header().Get("Retry-After"), http.StatusTooManyRequests)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	counters := skhron.New[int64]()
+	logs := skhron.New[[]time.Time]()
+	buckets := skhron.New[ratelimit.Bucket]()
+
+	tests := []struct {
+		name string
+		new  func() error
+	}{
+		{"fixed window without limit", func() error { _, err := ratelimit.NewFixedWindow(counters, 0, time.Minute); return err }},
+		{"fixed window without window", func() error { _, err := ratelimit.NewFixedWindow(counters, 1, 0); return err }},
+		{"sliding log with negative window", func() error { _, err := ratelimit.NewSlidingLog(logs, 1, -time.Minute); return err }},
+		{"token bucket without rate", func() error { _, err := ratelimit.NewTokenBucket(buckets, 0, 1); return err }},
+		{"token bucket with NaN rate", func() error { _, err := ratelimit.NewTokenBucket(buckets, math.NaN(), 1); return err }},
+		{"token bucket without burst", func() error { _, err := ratelimit.NewTokenBucket(buckets, 1, 0); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.new(); !errors.Is(err, ratelimit.ErrInvalidConfig) {
+				t.Errorf("error = %v, want %v", err, ratelimit.ErrInvalidConfig)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/dartt0n/skhron"
+)
+
+// SlidingLog is a limiter which allows `limit` requests per key in any window of time.
+// It keeps times of allowed requests, so it is exact, but uses memory proportional to the limit.
+type SlidingLog struct {
+	store  *skhron.Skhron[[]time.Time]
+	limit  int
+	window time.Duration
+}
+
+// NewSlidingLog is a function which creates a sliding window log limiter storing logs in `store`.
+// A log expires one window after the last allowed request.
+// If `limit` or `window` is not positive, ErrInvalidConfig is returned.
+func NewSlidingLog(store *skhron.Skhron[[]time.Time], limit int, window time.Duration) (*SlidingLog, error) {
+	if err := validateWindow(limit, window); err != nil {
+		return nil, err
+	}
+
+	return &SlidingLog{store: store, limit: limit, window: window}, nil
+}
+
+// Allow is a function which registers a request of the key (see ratelimit.Allow).
+func (l *SlidingLog) Allow(key string) (bool, time.Duration) {
+	return Allow(l, key)
+}
+
+// Take is a function which registers a request of the key.
+// Rejected requests are not logged.
+// If the log can not be stored (e.g. the storage is full), the request is rejected.
+func (l *SlidingLog) Take(key string) Result {
+	now := l.store.Clock.Now()
+	r := Result{Limit: l.limit}
+
+	_, err := l.store.Update(key, func(log []time.Time, _ bool) ([]time.Time, time.Duration) {
+		// drop requests which left the window; the stored slice may be shared with readers, so it is copied
+		start := now.Add(-l.window)
+		kept := make([]time.Time, 0, len(log)+1)
+		for _, t := range log {
+			if t.After(start) {
+				kept = append(kept, t)
+			}
+		}
+		log = kept
+
+		if len(log) < l.limit {
+			log = append(log, now)
+			r.Allowed = true
+		} else {
+			r.RetryAfter = log[len(log)-l.limit].Add(l.window).Sub(now)
+		}
+
+		r.Remaining = l.limit - len(log)
+		if len(log) > 0 {
+			r.Reset = log[len(log)-1].Add(l.window).Sub(now)
+		}
+
+		return log, l.window
+	})
+	if err != nil {
+		return deny(l.limit, l.window)
+	}
+
+	return r
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/dartt0n/skhron"
+)
+
+// Bucket is a state of a token bucket.
+type Bucket struct {
+	Tokens float64   `json:"tokens"`
+	Last   time.Time `json:"last"` // time the tokens were counted at
+}
+
+// TokenBucket is a limiter which refills every key with `rate` tokens per second up to `burst` tokens,
+// and each request takes a token. It allows bursts, while keeping the average rate.
+type TokenBucket struct {
+	store *skhron.Skhron[Bucket]
+	rate  float64
+	burst int
+}
+
+// NewTokenBucket is a function which creates a token bucket limiter storing buckets in `store`.
+// A bucket expires when it is full again, since a full bucket is the same as a missing one.
+// If `rate` or `burst` is not positive, ErrInvalidConfig is returned.
+func NewTokenBucket(store *skhron.Skhron[Bucket], rate float64, burst int) (*TokenBucket, error) {
+	if !(rate > 0) { // NaN is rejected as well
+		return nil, fmt.Errorf("%w: rate %v is not positive", ErrInvalidConfig, rate)
+	}
+	if burst <= 0 {
+		return nil, fmt.Errorf("%w: burst %d is not positive", ErrInvalidConfig, burst)
+	}
+
+	return &TokenBucket{store: store, rate: rate, burst: burst}, nil
+}
+
+// Allow is a function which registers a request of the key (see ratelimit.Allow).
+func (l *TokenBucket) Allow(key string) (bool, time.Duration) {
+	return Allow(l, key)
+}
+
+// Take is a function which registers a request of the key.
+// If the bucket can not be stored (e.g. the storage is full), the request is rejected.
+func (l *TokenBucket) Take(key string) Result {
+	now := l.store.Clock.Now()
+	r := Result{Limit: l.burst}
+
+	_, err := l.store.Update(key, func(b Bucket, ok bool) (Bucket, time.Duration) {
+		if !ok {
+			b = Bucket{Tokens: float64(l.burst), Last: now}
+		}
+
+		elapsed := max(now.Sub(b.Last), 0)
+		b.Tokens = min(b.Tokens+elapsed.Seconds()*l.rate, float64(l.burst))
+		b.Last = now
+
+		if b.Tokens >= 1 {
+			b.Tokens--
+			r.Allowed = true
+		} else {
+			r.RetryAfter = l.duration(1 - b.Tokens)
+		}
+
+		r.Remaining = int(math.Floor(b.Tokens))
+		r.Reset = l.duration(float64(l.burst) - b.Tokens)
+
+		return b, max(r.Reset, time.Millisecond)
+	})
+	if err != nil {
+		return deny(l.burst, l.duration(1))
+	}
+
+	return r
+}
+
+// duration is a function which returns time needed to refill `tokens` tokens.
+func (l *TokenBucket) duration(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / l.rate * float64(time.Second)))
+}
@@ -0,0 +1,83 @@
+package skhron
+
+import "time"
+
+// Schedule is a recurring wall-clock boundary (e.g. every midnight) used by `PutExpireAtNext`.
+type Schedule interface {
+	// Next returns the first boundary strictly after `t`.
+	Next(t time.Time) time.Time
+}
+
+// ScheduleFunc is an adapter to use a function as a Schedule.
+type ScheduleFunc func(t time.Time) time.Time
+
+func (f ScheduleFunc) Next(t time.Time) time.Time {
+	return f(t)
+}
+
+// Daily is a function which creates a schedule of every day at `hour`:`minute` in `loc`.
+// Days are counted in `loc`, so the boundary stays at the same wall-clock time across DST changes.
+func Daily(hour, minute int, loc *time.Location) Schedule {
+	return ScheduleFunc(func(t time.Time) time.Time {
+		t = t.In(loc)
+		next := time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, loc)
+		if !next.After(t) {
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, hour, minute, 0, 0, loc)
+		}
+		return next
+	})
+}
+
+// Midnight is a function which creates a schedule of every midnight in `loc`.
+func Midnight(loc *time.Location) Schedule {
+	return Daily(0, 0, loc)
+}
+
+// Weekly is a function which creates a schedule of every `day` of week at `hour`:`minute` in `loc`.
+func Weekly(day time.Weekday, hour, minute int, loc *time.Location) Schedule {
+	return ScheduleFunc(func(t time.Time) time.Time {
+		t = t.In(loc)
+		days := (int(day) - int(t.Weekday()) + 7) % 7
+		next := time.Date(t.Year(), t.Month(), t.Day()+days, hour, minute, 0, 0, loc)
+		if !next.After(t) {
+			next = time.Date(t.Year(), t.Month(), t.Day()+days+7, hour, minute, 0, 0, loc)
+		}
+		return next
+	})
+}
+
+// Every is a function which creates a schedule of multiples of `d` since the zero time
+// (e.g. `Every(time.Hour)` is the start of every hour in UTC).
+func Every(d time.Duration) Schedule {
+	return ScheduleFunc(func(t time.Time) time.Time {
+		return t.Truncate(d).Add(d)
+	})
+}
+
+// PutUntil is a function which puts a value in the storage under a key, which expires at `at`.
+// If `at` is not in the future, the key is removed by the next cleanup.
+// If the key is new and the storage is full (skhron.WithMaxKeys option), ErrKeyLimit is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) PutUntil(key string, value V, at time.Time) error {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.admit(key); err != nil {
+		return err
+	}
+
+	s.set(key, value)
+	s.schedule(key, at)
+
+	return nil
+}
+
+// PutExpireAtNext is a function which puts a value in the storage under a key,
+// which expires at the next boundary of the schedule (e.g. `PutExpireAtNext(key, value, Midnight(loc))`).
+// The boundary is computed once, when the value is put.
+// If the key is new and the storage is full (skhron.WithMaxKeys option), ErrKeyLimit is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) PutExpireAtNext(key string, value V, schedule Schedule) error {
+	return s.PutUntil(key, value, schedule.Next(s.Clock.Now()))
+}
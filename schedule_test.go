@@ -0,0 +1,82 @@
+package skhron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/skhrontest"
+)
+
+func TestSchedules(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		schedule skhron.Schedule
+		from     time.Time
+		want     time.Time
+	}{
+		{"midnight", skhron.Midnight(berlin), time.Date(2024, 3, 5, 13, 0, 0, 0, berlin), time.Date(2024, 3, 6, 0, 0, 0, 0, berlin)},
+		{"midnight at midnight", skhron.Midnight(berlin), time.Date(2024, 3, 6, 0, 0, 0, 0, berlin), time.Date(2024, 3, 7, 0, 0, 0, 0, berlin)},
+		{"midnight from utc", skhron.Midnight(berlin), time.Date(2024, 3, 5, 23, 30, 0, 0, time.UTC), time.Date(2024, 3, 7, 0, 0, 0, 0, berlin)},
+		{"daily over dst", skhron.Daily(3, 0, berlin), time.Date(2024, 3, 30, 12, 0, 0, 0, berlin), time.Date(2024, 3, 31, 3, 0, 0, 0, berlin)},
+		{"daily later today", skhron.Daily(18, 30, time.UTC), time.Date(2024, 3, 5, 13, 0, 0, 0, time.UTC), time.Date(2024, 3, 5, 18, 30, 0, 0, time.UTC)},
+		{"weekly", skhron.Weekly(time.Monday, 9, 0, time.UTC), time.Date(2024, 3, 5, 13, 0, 0, 0, time.UTC), time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC)},
+		{"weekly same day", skhron.Weekly(time.Tuesday, 9, 0, time.UTC), time.Date(2024, 3, 5, 8, 0, 0, 0, time.UTC), time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)},
+		{"every hour", skhron.Every(time.Hour), time.Date(2024, 3, 5, 13, 15, 0, 0, time.UTC), time.Date(2024, 3, 5, 14, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		if got := c.schedule.Next(c.from); !got.Equal(c.want) {
+			t.Errorf("%s: Next(%v) = %v, want %v", c.name, c.from, got, c.want)
+		}
+	}
+}
+
+func TestPutExpireAtNext(t *testing.T) {
+	dir := t.TempDir()
+	clock := skhrontest.NewFakeClock(time.Date(2024, 3, 5, 22, 0, 0, 0, time.UTC))
+	opts := []skhron.StorageOpt[int]{
+		skhron.WithClock[int](clock),
+		skhron.WithSnapshotDir[int](dir),
+		skhron.WithTempSnapshotDir[int](dir),
+	}
+	storage := skhron.New(opts...)
+
+	storage.PutExpireAtNext("quota", 10, skhron.Midnight(time.UTC))
+	storage.PutUntil("until", 1, time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC))
+
+	// absolute expirations survive snapshots
+	if err := storage.CreateSnapshot(); err != nil {
+		t.Fatalf("create snapshot failed: %v", err)
+	}
+	loaded := skhron.New(opts...)
+	if err := loaded.LoadSnapshot(); err != nil {
+		t.Fatalf("load snapshot failed: %v", err)
+	}
+
+	clock.Advance(time.Hour + 59*time.Minute)
+	loaded.CleanUpN(0)
+	if !loaded.Exists("quota") {
+		t.Errorf("key expired before midnight")
+	}
+
+	clock.Advance(2 * time.Minute)
+	loaded.CleanUpN(0)
+	if loaded.Exists("quota") {
+		t.Errorf("key did not expire at midnight")
+	}
+	if !loaded.Exists("until") {
+		t.Errorf("key expired before its time")
+	}
+
+	clock.Advance(12 * time.Hour)
+	loaded.CleanUpN(0)
+	if loaded.Exists("until") {
+		t.Errorf("key did not expire at its time")
+	}
+}
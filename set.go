@@ -0,0 +1,112 @@
+package skhron
+
+import (
+	"maps"
+	"slices"
+)
+
+// SAdd is a function which adds members to the set stored under the key. A missing set is created.
+// It returns the number of members which were not present in the set.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) SAdd(key string, members ...string) (int, error) {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.writeCollection(key, CollectionSet, len(members) > 0)
+	if err != nil || c == nil {
+		return 0, err
+	}
+
+	added := 0
+	for _, member := range members {
+		if _, ok := c.set[member]; !ok {
+			c.set[member] = struct{}{}
+			added++
+		}
+	}
+
+	if added > 0 {
+		s.changed(key, c)
+	}
+
+	return added, nil
+}
+
+// SRem is a function which removes members from the set stored under the key.
+// The set is removed when it becomes empty.
+// It returns the number of removed members.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) SRem(key string, members ...string) (int, error) {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.writeCollection(key, CollectionSet, false)
+	if err != nil || c == nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, member := range members {
+		if _, ok := c.set[member]; ok {
+			delete(c.set, member)
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		s.changed(key, c)
+	}
+
+	return removed, nil
+}
+
+// SMembers is a function which returns sorted members of the set stored under the key
+// (none if it is missing).
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) SMembers(key string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.readCollection(key, CollectionSet)
+	if err != nil || c == nil {
+		return []string{}, err
+	}
+
+	return slices.Sorted(maps.Keys(c.set)), nil
+}
+
+// SIsMember is a function which checks whether the member is in the set stored under the key.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) SIsMember(key, member string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.readCollection(key, CollectionSet)
+	if err != nil || c == nil {
+		return false, err
+	}
+
+	_, ok := c.set[member]
+	return ok, nil
+}
+
+// SCard is a function which returns the number of members of the set stored under the key.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) SCard(key string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.readCollection(key, CollectionSet)
+	if err != nil || c == nil {
+		return 0, err
+	}
+
+	return len(c.set), nil
+}
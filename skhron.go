@@ -4,10 +4,14 @@ import (
 	"container/heap"
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"maps"
+	"math/rand/v2"
 	"os"
 	"path"
 	"regexp"
+	"slices"
 	"sync"
 	"time"
 
@@ -27,6 +31,41 @@ type Skhron[V any] struct { // todo: serializable/deserializable type
 	// The cleaning up process takes an item from the front of the queue and checks,
 	// Whether the item has expired or not. Thus, we avoid scanning the whole map to find expired items.
 	TTLq *expireQueue `json:"ttlq,omitempty"`
+	// ttlIndex maps keys to their items in Skhron.TTLq, so the queue is never scanned.
+	ttlIndex map[string]*expireItem
+	// negatives is a set of keys known to be missing (see `PutNegative`).
+	// They share the queue with values, but are not present in Skhron.Data.
+	negatives map[string]struct{}
+	// colls are collections (lists, sets, hashes and sorted sets) stored under keys.
+	// They share the keyspace and the queue with values, but are not present in Skhron.Data.
+	colls map[string]*collection[V]
+	// fieldIndex maps keys of hashes and their fields to items of expiring fields in Skhron.TTLq.
+	fieldIndex map[string]map[string]*expireItem
+	// wake is signaled when the first item of Skhron.TTLq changes.
+	wake chan struct{}
+	// life is a state of background workers started by `Open`.
+	life lifecycle
+	// ordered is a sorted index of keys, nil unless skhron.WithOrderedIndex option is used.
+	// The pointer is never replaced after `New` (the index is reset in place), so it may be checked without the lock.
+	ordered *orderedIndex
+	// indexes are secondary indexes of values by names (see `AddIndex`).
+	indexes map[string]*secondaryIndex[V]
+	// versions maps keys to versions of their values. A version is taken from `seq`
+	// on every write, so it never repeats within the storage.
+	versions map[string]uint64
+	seq      uint64
+	// events delivers changes of keys to watchers.
+	events hub[Event[V]]
+	// messages delivers published messages to subscribers.
+	messages hub[Message[V]]
+	// children are namespaces of the storage created by `Namespace`.
+	children namespaces[V]
+	// parent is the storage of the namespace, nil for storages created by `New`.
+	parent *Skhron[V]
+	// stats counts operations of the storage.
+	stats counters
+	// rng is a random source of TTL jitter, guarded by the write lock.
+	rng *rand.Rand
 
 	// Config
 
@@ -36,6 +75,30 @@ type Skhron[V any] struct { // todo: serializable/deserializable type
 	SnapshotName string
 	// A directory where temporary files would be stored
 	TempSnapshotDir string
+	// A codec used to serialize values into snapshots
+	Codec ValueCodec[V]
+	// A clock used to read current time and create timers
+	Clock Clock
+	// Maximum number of items removed by the expiry scheduler per wake up
+	ExpiryBatch int
+	// Minimum time the expiry scheduler sleeps between wake ups
+	ExpiryGranularity time.Duration
+	// Maximum time a single adaptive cleanup cycle may take
+	CleanupBudget time.Duration
+	// Adaptive cleanup cycle repeats while the expired share of a batch is above this ratio
+	CleanupRepeatRatio float64
+	// Whether `Open` loads the latest snapshot and `Close` creates a new one
+	Persistent bool
+	// A period of background snapshots started by `Open` (zero disables them)
+	SnapshotInterval time.Duration
+	// Maximum number of keys in the storage (zero means no limit)
+	MaxKeys int
+	// TTL of keys put without explicit TTL (zero means they never expire)
+	DefaultTTL time.Duration
+	// Whether reads extend expiration of keys put with a TTL duration by that duration
+	SlidingExpiration bool
+	// Fraction of TTL durations, by which expirations are randomized (zero disables jitter)
+	TTLJitter float64
 }
 
 // Initialize Skhron instance with options.
@@ -43,8 +106,14 @@ func New[V any](opts ...StorageOpt[V]) *Skhron[V] {
 	skhron := &Skhron[V]{
 		mu: sync.RWMutex{},
 
-		Data: smap.New[string, V](0),
-		TTLq: newExpQueue(),
+		Data:       smap.New[string, V](0),
+		TTLq:       newExpQueue(),
+		ttlIndex:   make(map[string]*expireItem),
+		negatives:  make(map[string]struct{}),
+		colls:      make(map[string]*collection[V]),
+		fieldIndex: make(map[string]map[string]*expireItem),
+		wake:       make(chan struct{}, 1),
+		versions:   make(map[string]uint64),
 	}
 
 	heap.Init(skhron.TTLq) // initialize queue
@@ -59,75 +128,117 @@ func New[V any](opts ...StorageOpt[V]) *Skhron[V] {
 
 // Put is a function which puts a value in the storage under a key.
 // It takes the key as string and the value as V.
+// If `DefaultTTL` is set (skhron.WithDefaultTTL option), the key expires after it.
+// If the key is new and the storage is full (skhron.WithMaxKeys option), ErrKeyLimit is returned.
 // This function locks mutex for its operations.
 func (s *Skhron[V]) Put(key string, value V) error {
+	defer s.dispatch()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.Data.Set(key, value)
+	if err := s.admit(key); err != nil {
+		return err
+	}
+
+	s.set(key, value)
+	s.scheduleDefault(key, s.Clock.Now())
 
 	return nil
 }
 
 // PutTTL is a function which puts a value in the storage under a key with certain TTL.
 // It takes the key as string, the value as V and ttl as time.Duration.
-// It scans the entire queue to find if the item is already in the queue.
-// If it is, it updates the item and updates the queue (to maintain priority).
+// If sliding expiration is enabled (skhron.WithSlidingExpiration option), reads extend the TTL (see `PutSliding`).
+// Otherwise the TTL is randomized by `TTLJitter` (skhron.WithTTLJitter option) or by skhron.WithJitter option.
+// If the key is already in the queue, it updates the item and fixes the queue (to maintain priority).
 // If it is not, it puts the item into the queue.
+// If the item becomes the first one to expire, the expiry scheduler is woken up.
+// If the key is new and the storage is full (skhron.WithMaxKeys option), ErrKeyLimit is returned.
 // This function locks mutex for its operations.
-func (s *Skhron[V]) PutTTL(key string, value V, ttl time.Duration) error {
+func (s *Skhron[V]) PutTTL(key string, value V, ttl time.Duration, opts ...PutOpt) error {
+	defer s.dispatch()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.Data.Set(key, value)
-
-	// Find previous item
-	index := -1
-	for i := 0; i < s.TTLq.Len(); i++ { // iterate over the entire queue
-		if (*s.TTLq)[i].Key == key {
-			index = i
-			(*s.TTLq)[index].Exp = time.Now().Add(ttl) // update item ttl
-		}
+	if err := s.admit(key); err != nil {
+		return err
 	}
 
-	if index == -1 {
-		heap.Push(s.TTLq, &expireItem{Key: key, Exp: time.Now().Add(ttl)})
-	} else {
-		heap.Fix(s.TTLq, index) // update queue
+	s.set(key, value)
+	s.expireTTL(key, s.Clock.Now(), ttl, s.putOptions(opts).jitter)
+
+	return nil
+}
+
+// PutSliding is a function which puts a value in the storage under a key with sliding TTL:
+// the key expires after `ttl` since it was last put or read (by `Get`, `GetWithVersion` or `GetMany`).
+// Iteration does not extend the expiration.
+// If the key is new and the storage is full (skhron.WithMaxKeys option), ErrKeyLimit is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) PutSliding(key string, value V, ttl time.Duration) error {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.admit(key); err != nil {
+		return err
 	}
 
+	s.set(key, value)
+	s.expireAfter(key, s.Clock.Now(), ttl, true)
+
 	return nil
 }
 
 // Get is a function which fetches a value in the storage under a key.
 // It takes the key as string parameter.
-// If the key is not present, error is returned.
+// If the key has sliding TTL, its expiration is extended.
+// If the key is not present, error is returned: ErrNegativeCached for negative entries
+// (see `PutNegative`), ErrNoSuchKey otherwise.
 // This function locks mutex for its operations.
 func (s *Skhron[V]) Get(key string) (V, error) {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
+	v, ok := s.Data.Get2(key)
+	sliding := ok && s.sliding(key)
+	err := s.missing(key, ok)
+	s.mu.RUnlock()
 
-	if v, ok := s.Data.Get2(key); ok {
+	if sliding {
+		s.touch(key)
+	}
+	if ok {
 		return v, nil
 	}
 
-	return *new(V), errors.New("no such key: " + key)
+	return *new(V), err
+}
+
+// ErrNoSuchKey is returned (wrapped with the key) when the key is not present in the storage.
+var ErrNoSuchKey = errors.New("no such key")
+
+// ErrKeyLimit is returned when a new key is put into the storage, which already has `MaxKeys` keys.
+var ErrKeyLimit = errors.New("key limit reached")
+
+// noSuchKey is a function which creates an error for a missing key.
+func noSuchKey(key string) error {
+	return fmt.Errorf("%w: %s", ErrNoSuchKey, key)
 }
 
 // GetRegex is a function which fetches values in the storage
 // under keys, which match the mask regex.
 // It takes the regex as parameter.
-// This function locks mutex for its operations.
+// See `FindRegex` to fetch keys as well and to paginate the result.
+// This function locks mutex (for reading) for its operations.
 func (s *Skhron[V]) GetRegex(mask *regexp.Regexp) []V {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
 	data := s.Data.Values()
 
 	values := make([]V, 0, len(data))
 
 	for key, value := range data {
-		if mask.Match([]byte(key)) {
+		if mask.MatchString(key) {
 			values = append(values, value)
 		}
 	}
@@ -137,69 +248,187 @@ func (s *Skhron[V]) GetRegex(mask *regexp.Regexp) []V {
 
 // Delete is a function which deletes a key from the storage.
 // It takes the key as string parameter.
-// It does not delete the key from the queue, since when the key is expired
-// it would be deleted from the queue without side effects.
+// The key is removed from the queue as well, so a value put under the key later does not inherit its TTL.
 // This function locks mutex for its operations.
 func (s *Skhron[V]) Delete(key string) error {
+	defer s.dispatch()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.Data.Delete(key)
-
-	// todo: delete from queue?
-	// benefit: lower memory usage
-	// drawback: higher execution time
+	s.remove(key, EventDelete)
 
 	return nil
 }
 
 // Exists is a function which check wheater a key is present in the storage.
 // It takes the key as string parameter.
+// Keys holding collections are present as well.
 // This function locks mutex for its operations.
 func (s *Skhron[V]) Exists(key string) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	_, exist := s.Data.Get2(key)
-	return exist
+	_, coll := s.colls[key]
+	return exist || coll
 }
 
-// CleanUp is a function which removes expired items.
-// It is called periodically by the `PeriodicCleanup` function.
+// Len is a function which returns the number of keys in the storage (including keys holding collections).
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.len()
+}
+
+// len is a function which returns the number of keys in the storage.
+// The caller must hold the lock.
+func (s *Skhron[V]) len() int {
+	return len(s.Data.Values()) + len(s.colls)
+}
+
+// FlushAll is a function which removes all keys from the storage.
+// Watchers are notified with EventEvict. Namespaces are not affected.
+// It returns the number of removed keys.
 // This function locks mutex for its operations.
-func (s *Skhron[V]) CleanUp() {
+func (s *Skhron[V]) FlushAll() int {
+	defer s.dispatch()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	log.Printf("Skhron cleanup started\n")
+	keys := make([]string, 0, s.len())
+	for key := range s.Data.Values() {
+		keys = append(keys, key)
+	}
+	for key := range s.colls {
+		keys = append(keys, key)
+	}
 
-	now := time.Now()
-	deleted := 0
+	for _, key := range keys {
+		s.remove(key, EventEvict)
+	}
 
-	for s.TTLq.Len() > 0 {
-		item := heap.Pop(s.TTLq).(*expireItem)
+	s.TTLq = newExpQueue()
+	s.ttlIndex = make(map[string]*expireItem)
+	s.fieldIndex = make(map[string]map[string]*expireItem)
+	s.negatives = make(map[string]struct{})
 
-		if item.Exp.Before(now) {
-			log.Printf("Item with key \"%s\" expired %f sec ago, deleting\n", item.Key, now.Sub(item.Exp).Seconds())
-			s.Data.Delete(item.Key)
-			deleted++
-		} else {
-			heap.Push(s.TTLq, item)
-			break
-		}
+	return len(keys)
+}
+
+// admit is a function which checks whether the key may be put into the storage.
+// Existing keys are always admitted, new ones only while there are less than `MaxKeys` keys.
+// The caller must hold the lock.
+func (s *Skhron[V]) admit(key string) error {
+	if s.MaxKeys <= 0 {
+		return nil
+	}
+
+	if _, ok := s.Data.Get2(key); ok {
+		return nil
+	}
+	if _, ok := s.colls[key]; ok {
+		return nil
+	}
+
+	if s.len() >= s.MaxKeys {
+		return fmt.Errorf("%w: %s", ErrKeyLimit, key)
+	}
+
+	return nil
+}
+
+// set is a function which stores the value under the key and updates indexes.
+// The caller must hold the write lock.
+func (s *Skhron[V]) set(key string, value V) {
+	if _, ok := s.negatives[key]; ok {
+		delete(s.negatives, key)
+		s.unschedule(key)
+	}
+
+	// a value replaces a collection stored under the key
+	delete(s.colls, key)
+	s.unscheduleFields(key)
+
+	s.store(key, value)
+	s.stats.puts.Add(1)
+
+	s.seq++
+	s.versions[key] = s.seq
+
+	s.events.publish(Event[V]{Type: EventPut, Key: key, Value: value, Version: s.seq})
+}
+
+// store is a function which puts the value into the data and indexes, without versions, statistics and events.
+// The caller must hold the write lock.
+func (s *Skhron[V]) store(key string, value V) {
+	s.Data.Set(key, value)
+
+	if s.ordered != nil {
+		s.ordered.insert(key)
+	}
+	for _, index := range s.indexes {
+		index.insert(key, value)
+	}
+}
+
+// remove is a function which deletes the key from the storage, the queue and indexes.
+// The reason is reported to watchers, if the key was present.
+// The caller must hold the write lock.
+func (s *Skhron[V]) remove(key string, reason EventType) {
+	s.unschedule(key)
+
+	if value, ok := s.Data.Get2(key); ok {
+		s.events.publish(Event[V]{Type: reason, Key: key, Value: value, Version: s.versions[key]})
+		s.stats.removed(reason)
+	} else if _, ok := s.colls[key]; ok {
+		s.events.publish(Event[V]{Type: reason, Key: key, Version: s.versions[key]})
+		s.stats.removed(reason)
+		delete(s.colls, key)
+		s.unscheduleFields(key)
 	}
 
-	log.Printf("Skhron cleanup finished. %d keys deleted, %d left in queue\n", deleted, s.TTLq.Len())
+	delete(s.negatives, key)
+
+	s.Data.Delete(key)
+	delete(s.versions, key)
+
+	if s.ordered != nil {
+		s.ordered.delete(key)
+	}
+	for _, index := range s.indexes {
+		index.delete(key)
+	}
+}
+
+// CleanUp is a function which removes expired items.
+// Items are removed in batches (see `CleanUpN`), so readers are not blocked for the entire sweep.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) CleanUp() {
+	log.Printf("Skhron cleanup started\n")
+
+	deleted := s.CleanUpN(0)
+
+	s.mu.RLock()
+	left := s.TTLq.Len()
+	s.mu.RUnlock()
+
+	log.Printf("Skhron cleanup finished. %d keys deleted, %d left in queue\n", deleted, left)
 }
 
 // `PeriodicCleanup` is a function that
-// periodically calls the `CleanUp` function.
+// periodically runs an adaptive cleanup cycle (see `CleanUpAdaptive`).
 // It works until `ctx.Done()` signal is sent.
-// It puts into `done` channel when it finishes.
+// It closes `done` channel (if it is not nil) when it finishes.
 // It backups current state of the storage into file `./skhron/skhron_{timestamp}.json` on exit.
 // It runs clean up process every `period` time duration.
 func (s *Skhron[V]) PeriodicCleanup(ctx context.Context, period time.Duration, done chan struct{}) {
 	log.Printf("Starting cleaning up process with period %.02f sec\n", period.Seconds())
+
+	// the timer is stopped on exit, so it does not outlive the process (e.g. in a fake clock)
+	timer := s.Clock.NewTimer(period)
+	defer timer.Stop()
 loop:
 	for {
 		select {
@@ -212,22 +441,73 @@ loop:
 
 			log.Println("Shutting down skhron cleanup process")
 			break loop
-		case <-time.After(period):
-			s.CleanUp()
+		case <-timer.C():
+			if deleted := s.CleanUpAdaptive(); deleted > 0 {
+				log.Printf("Skhron cleanup cycle finished. %d keys deleted\n", deleted)
+			}
+			timer.Reset(period)
 		}
 	}
 
-	done <- struct{}{}
+	if done != nil {
+		close(done)
+	}
 }
 
-// JsonMarshal is a function, which converts the struct into JSON-string bytes
+// snapshotVersion is a version of the snapshot format.
+// Version 1 (no "version" field) stores values as plain JSON,
+// version 2 stores values encoded with Skhron.Codec as base64 strings,
+// version 3 stores values encoded with JSONCodec as plain JSON ("encoding" is "json")
+// and values encoded with other codecs as base64 strings ("encoding" is "binary").
+const snapshotVersion = 3
+
+// Encodings of values in the snapshot (see snapshotVersion).
+const (
+	encodingJSON   = "json"
+	encodingBinary = "binary"
+)
+
+// JsonMarshal is a function, which converts the struct into JSON-string bytes.
+// Values are encoded with Skhron.Codec. Values encoded with JSONCodec (the default codec)
+// are embedded as is, so the snapshot stays human-readable.
 func (s *Skhron[V]) MarshalJSON() ([]byte, error) {
+	// namespaces are listed before locking, since `Namespace` locks the registry before the mutex
+	names := s.Namespaces()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	data := make(map[string]json.RawMessage, len(s.Data.Values()))
+	for key, value := range s.Data.Values() {
+		encoded, err := s.encodeSnapshotValue(value)
+		if err != nil {
+			return []byte{}, fmt.Errorf("failed to encode value of key %q: %w", key, err)
+		}
+		data[key] = encoded
+	}
+
+	// negative entries are not persisted
+	ttlq := make(expireQueue, 0, s.TTLq.Len())
+	for _, item := range *s.TTLq {
+		if _, ok := s.negatives[item.Key]; !ok || item.IsField {
+			ttlq = append(ttlq, item)
+		}
+	}
+
+	colls, err := s.encodeCollections()
+	if err != nil {
+		return []byte{}, err
+	}
+
 	bytes, err := json.Marshal(map[string]interface{}{
-		"data": s.Data.Values(),
-		"ttlq": s.TTLq,
+		"version":     snapshotVersion,
+		"encoding":    s.snapshotEncoding(),
+		"data":        data,
+		"collections": colls,
+		"ttlq":        ttlq,
+		"versions":    s.versions,
+		"seq":         s.seq,
+		"namespaces":  names,
 	})
 
 	if err != nil {
@@ -241,8 +521,22 @@ func (s *Skhron[V]) MarshalJSON() ([]byte, error) {
 // in the temporary directory.
 // Then it checks if an older snapshot exists in snapshot directory.
 // If it is, it renames it to format "{snapshot name}_{time stamp}.skh"
-// and then moves new snapshot to the snapshot directory
+// and then moves new snapshot to the snapshot directory.
+// Snapshots of namespaces are created as well.
 func (s *Skhron[V]) CreateSnapshot() error {
+	errs := []error{s.writeSnapshot()}
+
+	s.eachNamespace(func(name string, ns *Skhron[V]) {
+		if err := ns.CreateSnapshot(); err != nil {
+			errs = append(errs, fmt.Errorf("namespace %q: %w", name, err))
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// writeSnapshot is a function which creates snapshot of the storage without its namespaces.
+func (s *Skhron[V]) writeSnapshot() error {
 	// Marshal stroge to json
 	bytes, err := s.MarshalJSON()
 	if err != nil {
@@ -255,17 +549,15 @@ func (s *Skhron[V]) CreateSnapshot() error {
 		return err
 	}
 
-	timestamp := time.Now().Format("_2006_01_02_15:04:05")
-
-	// create temp file
-	tmpName := "skhron" + timestamp + ".json"
-	tmpFilepath := path.Join(s.TempSnapshotDir, tmpName)
+	timestamp := s.Clock.Now().Format("_2006_01_02_15:04:05")
 
-	f, err := os.Create(tmpFilepath)
+	// create temp file with a unique name, so concurrent snapshots (e.g. of namespaces) never share it
+	f, err := os.CreateTemp(s.TempSnapshotDir, "skhron"+timestamp+"_*.json")
 	if err != nil {
 		return err
 	}
 	defer f.Close()
+	tmpFilepath := f.Name()
 
 	// write json
 	_, err = f.Write(bytes)
@@ -302,8 +594,25 @@ func (s *Skhron[V]) CreateSnapshot() error {
 // LoadSnapshot is a function, which loads data
 // from the latest snapshot file and writes data to the Skhron object.
 // It looks for file {snapshot dir}/{snapshot file}.skh
+// Namespaces listed in the snapshot are created and their snapshots are loaded as well.
+// Watchers are notified with EventEvict for keys which are not in the snapshot
+// and with EventPut (carrying restored versions) for loaded keys.
 // If load is failed, error is returned.
 func (s *Skhron[V]) LoadSnapshot() error {
+	names, err := s.loadSnapshot()
+	if err != nil {
+		return err
+	}
+
+	// namespaces are loaded without holding the lock
+	return s.loadNamespaces(names)
+}
+
+// loadSnapshot is a function which loads the snapshot of the storage without its namespaces.
+// It returns names of the namespaces listed in the snapshot.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) loadSnapshot() ([]string, error) {
+	defer s.dispatch()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -311,36 +620,158 @@ func (s *Skhron[V]) LoadSnapshot() error {
 
 	f, err := os.Open(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer f.Close()
 
 	rs := &struct {
-		Data map[string]V
-		TTLq *expireQueue
+		Version     int
+		Encoding    string
+		Data        map[string]json.RawMessage
+		Collections map[string]snapshotCollection
+		TTLq        *expireQueue
+		Versions    map[string]uint64
+		Seq         uint64
+		Namespaces  []string
 	}{}
 
 	dec := json.NewDecoder(f)
 	if err := dec.Decode(rs); err != nil {
-		return err
+		return nil, err
+	}
+
+	data := make(map[string]V, len(rs.Data))
+	for key, raw := range rs.Data {
+		value, err := s.decodeSnapshotValue(rs.Version, rs.Encoding, raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode value of key %q: %w", key, err)
+		}
+		data[key] = value
+	}
+
+	colls, err := s.decodeCollections(rs.Version, rs.Encoding, rs.Collections)
+	if err != nil {
+		return nil, err
+	}
+
+	// keys which are not in the snapshot are evicted
+	for key, value := range s.Data.Values() {
+		if _, ok := data[key]; !ok && colls[key] == nil {
+			s.events.publish(Event[V]{Type: EventEvict, Key: key, Value: value, Version: s.versions[key]})
+			s.stats.removed(EventEvict)
+		}
+	}
+	for key := range s.colls {
+		if _, ok := data[key]; !ok && colls[key] == nil {
+			s.events.publish(Event[V]{Type: EventEvict, Key: key, Version: s.versions[key]})
+			s.stats.removed(EventEvict)
+		}
 	}
 
 	// reset old skhron data
 	limit := s.Data.GetLimit()
 	s.Data = smap.New[string, V](limit)
 	s.TTLq = newExpQueue()
+	s.ttlIndex = make(map[string]*expireItem)
+	s.fieldIndex = make(map[string]map[string]*expireItem)
+	s.negatives = make(map[string]struct{})
+	s.colls = make(map[string]*collection[V])
+	s.versions = make(map[string]uint64)
+	if s.ordered != nil {
+		s.ordered.reset()
+	}
+	for name, index := range s.indexes {
+		s.indexes[name] = newSecondaryIndex(index.extract)
+	}
+
+	// add items
+	for key, value := range data {
+		s.store(key, value)
+	}
+	for key, c := range colls {
+		s.colls[key] = c
+	}
+
+	// restore versions, so they keep increasing after restart
+	s.seq = max(s.seq, rs.Seq)
+	for key, version := range rs.Versions {
+		if _, ok := data[key]; ok || colls[key] != nil {
+			s.versions[key] = version
+			s.seq = max(s.seq, version)
+		}
+	}
+
+	// keys of snapshots without versions get new ones, then watchers are notified with the versions
+	// `GetWithVersion` returns
+	loaded := slices.Concat(slices.Collect(maps.Keys(data)), slices.Collect(maps.Keys(colls)))
+	slices.Sort(loaded)
+	for _, key := range loaded {
+		if _, ok := s.versions[key]; !ok {
+			s.seq++
+			s.versions[key] = s.seq
+		}
+		s.events.publish(Event[V]{Type: EventPut, Key: key, Value: data[key], Version: s.versions[key]})
+	}
+
+	// add ttl items of the keys (and the fields of hashes) which are still present
+	if rs.TTLq != nil {
+		for _, item := range *rs.TTLq {
+			if item.IsField {
+				if c := colls[item.Key]; c != nil && c.kind == CollectionHash {
+					if _, ok := c.hash[item.Field]; ok {
+						s.TTLq.Push(item)
+						s.indexField(item)
+					}
+				}
+				continue
+			}
+
+			if _, ok := data[item.Key]; ok || colls[item.Key] != nil {
+				s.TTLq.Push(item)
+				s.ttlIndex[item.Key] = item
+			}
+		}
+	}
 	heap.Init(s.TTLq)
+	s.wakeScheduler()
+
+	return rs.Namespaces, nil
+}
+
+// snapshotEncoding is a function which returns the encoding of values in the snapshot (see snapshotVersion).
+func (s *Skhron[V]) snapshotEncoding() string {
+	if _, ok := s.Codec.(JSONCodec[V]); ok {
+		return encodingJSON
+	}
+	return encodingBinary
+}
 
-	// add no-ttl items
-	for key, value := range rs.Data {
-		s.Data.Set(key, value)
+// encodeSnapshotValue is a function which encodes a single value for the snapshot:
+// JSON produced by JSONCodec is embedded as is, other encoded values are written as base64 strings.
+func (s *Skhron[V]) encodeSnapshotValue(value V) (json.RawMessage, error) {
+	encoded, err := s.Codec.Marshal(value)
+	if err != nil {
+		return nil, err
 	}
 
-	// add ttl items
-	for rs.TTLq.Len() > 0 {
-		item := rs.TTLq.Pop().(*expireItem)
-		s.Data.Set(item.Key, rs.Data[item.Key])
-		s.TTLq.Push(item)
+	if s.snapshotEncoding() == encodingJSON {
+		return encoded, nil
 	}
 
-	return nil
+	return json.Marshal(encoded)
+}
+
+// decodeSnapshotValue is a function which decodes a single value from the snapshot.
+// Snapshots created before the codec was introduced and snapshots with JSON encoding contain plain JSON values.
+func (s *Skhron[V]) decodeSnapshotValue(version int, encoding string, raw json.RawMessage) (V, error) {
+	if version < 2 || encoding == encodingJSON {
+		return JSONCodec[V]{}.Unmarshal(raw)
+	}
+
+	var encoded []byte
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		return *new(V), err
+	}
+
+	return s.Codec.Unmarshal(encoded)
 }
@@ -0,0 +1,171 @@
+// Package skhrontest provides helpers for testing code which uses skhron.
+package skhrontest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dartt0n/skhron"
+)
+
+// FakeClock is a skhron.Clock which only moves when Advance or Set is called.
+// It is safe for concurrent use.
+type FakeClock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock is a function which creates a fake clock pointing at `now`.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns current fake time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// After returns a channel which receives fake time once the clock is advanced by `d`.
+// The timer behind the channel can not be stopped, so it is counted by Waiters and BlockUntil until it fires:
+// code which may stop waiting earlier should use NewTimer and stop the timer.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// NewTimer creates a timer which fires once the clock is advanced by `d`.
+func (c *FakeClock) NewTimer(d time.Duration) skhron.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, ch: make(chan time.Time, 1)}
+	c.schedule(t, d)
+
+	return t
+}
+
+// Advance moves the clock forward by `d` and fires all timers which are due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+// Set moves the clock to `now` and fires all timers which are due.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+	c.fire()
+}
+
+// Waiters is a function which returns the number of timers waiting on the clock
+// (created and not stopped or fired yet).
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+// BlockUntil is a function which blocks until at least `n` timers are waiting on the clock (see Waiters).
+// It is used to make sure a goroutine has armed its timer before the clock is advanced.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// schedule registers timer `t` to fire after `d`. Clock mutex must be held.
+func (c *FakeClock) schedule(t *fakeTimer, d time.Duration) {
+	t.deadline = c.now.Add(d)
+	if d <= 0 {
+		t.send(c.now)
+		return
+	}
+
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+}
+
+// unschedule removes timer `t` from the clock. Clock mutex must be held.
+func (c *FakeClock) unschedule(t *fakeTimer) bool {
+	for i, timer := range c.timers {
+		if timer == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// fire sends current time to all timers which are due. Clock mutex must be held.
+func (c *FakeClock) fire() {
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(c.now) {
+			pending = append(pending, t)
+		} else {
+			t.send(c.now)
+		}
+	}
+	c.timers = pending
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	ch       chan time.Time
+	deadline time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.drain()
+	return t.clock.unschedule(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.drain()
+	active := t.clock.unschedule(t)
+	t.clock.schedule(t, d)
+
+	return active
+}
+
+// send delivers the time to the timer channel without blocking, like time.Timer does.
+func (t *fakeTimer) send(now time.Time) {
+	select {
+	case t.ch <- now:
+	default:
+	}
+}
+
+// drain removes stale time from the timer channel, like time.Timer does since Go 1.23.
+func (t *fakeTimer) drain() {
+	select {
+	case <-t.ch:
+	default:
+	}
+}
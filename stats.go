@@ -0,0 +1,56 @@
+package skhron
+
+import "sync/atomic"
+
+// Stats is a summary of the storage state and operations since it was created.
+type Stats struct {
+	Keys         int    // number of keys in the storage
+	Negatives    int    // number of negative entries (see `PutNegative`), not counted in Keys
+	Hits         uint64 // lookups of present keys
+	Misses       uint64 // lookups of missing keys
+	NegativeHits uint64 // lookups of negative entries, not counted in Misses
+	Puts         uint64 // values written to the storage (not including the ones loaded from snapshots)
+	Deletes      uint64 // keys deleted by users
+	Expired      uint64 // keys removed after their TTL
+	Evicted      uint64 // keys removed by the storage itself (e.g. by `FlushAll` or `LoadSnapshot`)
+}
+
+// counters is a set of operation counters. They are updated atomically,
+// so lookups holding the read lock can count as well.
+type counters struct {
+	hits, misses, negativeHits, puts, deletes, expired, evicted atomic.Uint64
+}
+
+// removed is a function which counts a removal of a key.
+func (c *counters) removed(reason EventType) {
+	switch reason {
+	case EventDelete:
+		c.deletes.Add(1)
+	case EventExpire:
+		c.expired.Add(1)
+	case EventEvict:
+		c.evicted.Add(1)
+	}
+}
+
+// Stats is a function which returns statistics of the storage.
+// Namespaces have their own statistics.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) Stats() Stats {
+	s.mu.RLock()
+	keys := s.len()
+	negatives := len(s.negatives)
+	s.mu.RUnlock()
+
+	return Stats{
+		Keys:         keys,
+		Negatives:    negatives,
+		Hits:         s.stats.hits.Load(),
+		Misses:       s.stats.misses.Load(),
+		NegativeHits: s.stats.negativeHits.Load(),
+		Puts:         s.stats.puts.Load(),
+		Deletes:      s.stats.deletes.Load(),
+		Expired:      s.stats.expired.Load(),
+		Evicted:      s.stats.evicted.Load(),
+	}
+}
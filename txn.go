@@ -0,0 +1,187 @@
+package skhron
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrConflict is returned by Txn when a key read or watched by the transaction
+// was changed by someone else before the transaction was committed.
+// The transaction may be retried.
+var ErrConflict = errors.New("transaction conflict")
+
+// Tx is a transaction created by Skhron.Txn.
+// Writes are staged in the transaction and are not visible to others until the commit.
+// Reads see staged writes of the transaction. Every key read from the storage is watched:
+// if it is changed before the commit, the transaction fails with ErrConflict.
+// Tx is not safe for concurrent use.
+type Tx[V any] struct {
+	s       *Skhron[V]
+	watched map[string]uint64 // versions of watched keys, zero for missing keys
+	writes  map[string]txWrite[V]
+	order   []string // keys in order of the first write
+}
+
+type txWrite[V any] struct {
+	value   V
+	ttl     time.Duration
+	jitter  float64
+	hasTTL  bool
+	deleted bool
+}
+
+// Txn is a function which runs `fn` in a transaction.
+// If `fn` returns an error, staged writes are discarded and the error is returned.
+// Otherwise the transaction is committed atomically: if none of the watched keys has changed,
+// all staged writes are applied under a single lock acquisition, else ErrConflict is returned.
+// If new keys do not fit into the storage (skhron.WithMaxKeys option), nothing is applied and ErrKeyLimit is returned.
+// The lock is not held while `fn` runs, so `fn` should not block for long.
+func (s *Skhron[V]) Txn(fn func(tx *Tx[V]) error) error {
+	tx := &Tx[V]{
+		s:       s,
+		watched: make(map[string]uint64),
+		writes:  make(map[string]txWrite[V]),
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.commit()
+}
+
+// Watch is a function which makes the transaction fail with ErrConflict
+// if any of the keys is changed before the commit.
+// Keys which were already read or watched keep their first version.
+// This function locks mutex (for reading) for its operations.
+func (tx *Tx[V]) Watch(keys ...string) {
+	tx.s.mu.RLock()
+	defer tx.s.mu.RUnlock()
+
+	for _, key := range keys {
+		tx.watch(key)
+	}
+}
+
+// Get is a function which fetches a value under a key, taking staged writes into account.
+// The key is watched.
+// If the key is not present, error is returned.
+// This function locks mutex (for reading) for its operations.
+func (tx *Tx[V]) Get(key string) (V, error) {
+	if w, ok := tx.writes[key]; ok {
+		if w.deleted {
+			return *new(V), noSuchKey(key)
+		}
+		return w.value, nil
+	}
+
+	tx.s.mu.RLock()
+	defer tx.s.mu.RUnlock()
+
+	tx.watch(key)
+
+	if v, ok := tx.s.Data.Get2(key); ok {
+		return v, nil
+	}
+
+	return *new(V), noSuchKey(key)
+}
+
+// Put is a function which stages putting a value under a key (see Skhron.Put).
+func (tx *Tx[V]) Put(key string, value V) {
+	tx.stage(key, txWrite[V]{value: value})
+}
+
+// PutTTL is a function which stages putting a value under a key with certain TTL (see Skhron.PutTTL).
+// TTL is counted from the commit time.
+func (tx *Tx[V]) PutTTL(key string, value V, ttl time.Duration, opts ...PutOpt) {
+	tx.stage(key, txWrite[V]{value: value, ttl: ttl, jitter: tx.s.putOptions(opts).jitter, hasTTL: true})
+}
+
+// Delete is a function which stages deleting a key (see Skhron.Delete).
+func (tx *Tx[V]) Delete(key string) {
+	tx.stage(key, txWrite[V]{deleted: true})
+}
+
+// watch is a function which remembers current version of the key, if it is not watched yet.
+// The caller must hold the lock.
+func (tx *Tx[V]) watch(key string) {
+	if _, ok := tx.watched[key]; !ok {
+		tx.watched[key] = tx.s.versions[key]
+	}
+}
+
+func (tx *Tx[V]) stage(key string, w txWrite[V]) {
+	if _, ok := tx.writes[key]; !ok {
+		tx.order = append(tx.order, key)
+	}
+	tx.writes[key] = w
+}
+
+// commit is a function which validates watched keys and applies staged writes.
+// This function locks mutex for its operations.
+func (tx *Tx[V]) commit() error {
+	s := tx.s
+
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, version := range tx.watched {
+		if s.versions[key] != version {
+			return ErrConflict
+		}
+	}
+
+	if err := tx.admit(); err != nil {
+		return err
+	}
+
+	now := s.Clock.Now()
+	for _, key := range tx.order {
+		w := tx.writes[key]
+		switch {
+		case w.deleted:
+			s.remove(key, EventDelete)
+		case w.hasTTL:
+			s.set(key, w.value)
+			s.expireTTL(key, now, w.ttl, w.jitter)
+		default:
+			s.set(key, w.value)
+			s.scheduleDefault(key, now)
+		}
+	}
+
+	return nil
+}
+
+// admit is a function which checks whether the storage has room for new keys of the transaction
+// (skhron.WithMaxKeys option). Keys deleted by the transaction free the room.
+// The caller must hold the write lock.
+func (tx *Tx[V]) admit() error {
+	s := tx.s
+	if s.MaxKeys <= 0 {
+		return nil
+	}
+
+	keys, added := s.len(), false
+	for _, key := range tx.order {
+		_, present := s.Data.Get2(key)
+		if _, ok := s.colls[key]; ok {
+			present = true
+		}
+		switch w := tx.writes[key]; {
+		case w.deleted && present:
+			keys--
+		case !w.deleted && !present:
+			keys++
+			added = true
+		}
+	}
+
+	if added && keys > s.MaxKeys {
+		return ErrKeyLimit
+	}
+
+	return nil
+}
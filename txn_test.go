@@ -0,0 +1,161 @@
+package skhron
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTxnCommit(t *testing.T) {
+	s := New[int]()
+	s.Put("session:old", 42)
+
+	// rename a session
+	err := s.Txn(func(tx *Tx[int]) error {
+		v, err := tx.Get("session:old")
+		if err != nil {
+			return err
+		}
+		tx.Delete("session:old")
+		tx.PutTTL("session:new", v, time.Hour)
+
+		// staged writes are visible inside the transaction
+		if _, err := tx.Get("session:old"); err == nil {
+			t.Errorf("deleted key is visible inside the transaction")
+		}
+		if v, err := tx.Get("session:new"); err != nil || v != 42 {
+			t.Errorf("staged key is not visible inside the transaction")
+		}
+
+		// but not outside of it
+		if s.Exists("session:new") {
+			t.Errorf("staged key is visible outside the transaction")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Txn() error = %v", err)
+	}
+
+	if s.Exists("session:old") {
+		t.Errorf("key session:old was not deleted")
+	}
+	if v, err := s.Get("session:new"); err != nil || v != 42 {
+		t.Errorf("Get(session:new) = %v, %v, want 42", v, err)
+	}
+	if _, ok := s.ttlIndex["session:new"]; !ok {
+		t.Errorf("key session:new was put without TTL")
+	}
+}
+
+func TestTxnRollback(t *testing.T) {
+	s := New[int]()
+	s.Put("a", 1)
+
+	fail := errors.New("insufficient quota")
+	err := s.Txn(func(tx *Tx[int]) error {
+		tx.Put("a", 2)
+		tx.Put("b", 3)
+		return fail
+	})
+	if !errors.Is(err, fail) {
+		t.Errorf("Txn() error = %v, want %v", err, fail)
+	}
+
+	if v, _ := s.Get("a"); v != 1 || s.Exists("b") {
+		t.Errorf("writes of the failed transaction were applied")
+	}
+}
+
+func TestTxnConflict(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(s *Skhron[int], tx *Tx[int])
+	}{
+		{
+			name: "read key changed",
+			fn: func(s *Skhron[int], tx *Tx[int]) {
+				tx.Get("a")
+				s.Put("a", 10)
+			},
+		},
+		{
+			name: "missing key created",
+			fn: func(s *Skhron[int], tx *Tx[int]) {
+				tx.Get("missing")
+				s.Put("missing", 10)
+			},
+		},
+		{
+			name: "watched key deleted",
+			fn: func(s *Skhron[int], tx *Tx[int]) {
+				tx.Watch("a")
+				s.Delete("a")
+			},
+		},
+		{
+			name: "watched key rewritten with the same value",
+			fn: func(s *Skhron[int], tx *Tx[int]) {
+				tx.Watch("a")
+				s.Put("a", 1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New[int]()
+			s.Put("a", 1)
+
+			err := s.Txn(func(tx *Tx[int]) error {
+				tt.fn(s, tx)
+				tx.Put("b", 2)
+				return nil
+			})
+
+			if !errors.Is(err, ErrConflict) {
+				t.Errorf("Txn() error = %v, want %v", err, ErrConflict)
+			}
+			if s.Exists("b") {
+				t.Errorf("writes of the conflicting transaction were applied")
+			}
+		})
+	}
+}
+
+func TestTxnTransferSerializable(t *testing.T) {
+	s := New[int]()
+	s.Put("from", 1000)
+	s.Put("to", 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for transferred := 0; transferred < 10; {
+				err := s.Txn(func(tx *Tx[int]) error {
+					from, _ := tx.Get("from")
+					to, _ := tx.Get("to")
+					tx.Put("from", from-1)
+					tx.Put("to", to+1)
+					return nil
+				})
+				if err == nil {
+					transferred++
+				} else if !errors.Is(err, ErrConflict) {
+					t.Errorf("Txn() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	from, _ := s.Get("from")
+	to, _ := s.Get("to")
+	if from != 900 || to != 100 {
+		t.Errorf("from = %d, to = %d, want 900 and 100", from, to)
+	}
+}
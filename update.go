@@ -0,0 +1,48 @@
+package skhron
+
+import "time"
+
+// Update is a function which atomically replaces the value under the key with the result of `fn`.
+// `fn` receives current value of the key and whether the key is present
+// (a key which has expired, but has not been removed by cleanup yet, is reported as missing).
+// If `fn` returns a positive TTL, the key expires after it, otherwise the key keeps its expiration
+// (a new key expires after `DefaultTTL`, if it is set by skhron.WithDefaultTTL option).
+// `fn` runs while the lock is held, so it must be fast and must not call the storage.
+// If the key holds a collection, ErrWrongType is returned.
+// If the key is new and the storage is full (skhron.WithMaxKeys option), ErrKeyLimit is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) Update(key string, fn func(value V, ok bool) (V, time.Duration)) (V, error) {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Clock.Now()
+
+	current, ok := s.Data.Get2(key)
+	if s.expired(key, now) {
+		s.remove(key, EventExpire)
+		current, ok = *new(V), false
+	}
+
+	if _, coll := s.colls[key]; coll {
+		return *new(V), wrongType(key)
+	}
+
+	if !ok {
+		if err := s.admit(key); err != nil {
+			return *new(V), err
+		}
+	}
+
+	value, ttl := fn(current, ok)
+
+	s.set(key, value)
+	switch {
+	case ttl > 0:
+		s.schedule(key, now.Add(ttl))
+	case !ok:
+		s.scheduleDefault(key, now)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,81 @@
+package skhron_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dartt0n/skhron"
+	"github.com/dartt0n/skhron/skhrontest"
+)
+
+func TestUpdate(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(skhron.WithClock[int](clock))
+
+	increment := func(value int, ok bool) (int, time.Duration) {
+		if !ok {
+			return 1, 0
+		}
+		return value + 1, 0
+	}
+
+	// a missing key is reported as missing
+	if v, err := storage.Update("key", func(value int, ok bool) (int, time.Duration) {
+		if ok || value != 0 {
+			t.Errorf("fn(%d, %v) for missing key, want 0, false", value, ok)
+		}
+		return 1, time.Minute
+	}); err != nil || v != 1 {
+		t.Errorf("Update(key) = %d, %v, want 1, nil", v, err)
+	}
+
+	// the key keeps its expiration, unless fn returns a new TTL
+	clock.Advance(30 * time.Second)
+	storage.Update("key", increment)
+	if ttl, err := storage.TTL("key"); err != nil || ttl != 30*time.Second {
+		t.Errorf("TTL(key) after update = %v, %v, want 30s, nil", ttl, err)
+	}
+	storage.Update("key", func(value int, ok bool) (int, time.Duration) { return value, time.Hour })
+	if ttl, err := storage.TTL("key"); err != nil || ttl != time.Hour {
+		t.Errorf("TTL(key) after update with TTL = %v, %v, want 1h, nil", ttl, err)
+	}
+
+	// an expired key, which has not been removed by cleanup yet, is reported as missing
+	clock.Advance(2 * time.Hour)
+	if v, err := storage.Update("key", increment); err != nil || v != 1 {
+		t.Errorf("Update(key) after expiry = %d, %v, want 1, nil", v, err)
+	}
+	if ttl, err := storage.TTL("key"); err != nil || ttl != 0 {
+		t.Errorf("TTL(key) after update of expired key = %v, %v, want 0, nil", ttl, err)
+	}
+
+	storage.LPush("list", 1)
+	if _, err := storage.Update("list", increment); !errors.Is(err, skhron.ErrWrongType) {
+		t.Errorf("Update(list) = %v, want %v", err, skhron.ErrWrongType)
+	}
+}
+
+func TestUpdateLimits(t *testing.T) {
+	clock := skhrontest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := skhron.New(
+		skhron.WithClock[int](clock),
+		skhron.WithMaxKeys[int](1),
+		skhron.WithDefaultTTL[int](time.Minute),
+	)
+
+	keep := func(value int, ok bool) (int, time.Duration) { return value + 1, 0 }
+
+	// a new key gets the default TTL
+	storage.Update("first", keep)
+	if ttl, err := storage.TTL("first"); err != nil || ttl != time.Minute {
+		t.Errorf("TTL(first) = %v, %v, want 1m, nil", ttl, err)
+	}
+
+	if _, err := storage.Update("second", keep); !errors.Is(err, skhron.ErrKeyLimit) {
+		t.Errorf("Update(second) over the limit = %v, want %v", err, skhron.ErrKeyLimit)
+	}
+	if v, err := storage.Update("first", keep); err != nil || v != 2 {
+		t.Errorf("Update(first) over the limit = %d, %v, want 2, nil", v, err)
+	}
+}
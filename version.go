@@ -0,0 +1,79 @@
+package skhron
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrVersionMismatch is returned when the expected version of the key differs from the current one.
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// GetWithVersion is a function which fetches a value in the storage under a key with its version.
+// Versions increase monotonically: every write of any key gets a version greater than all previous ones.
+// If the key has sliding TTL, its expiration is extended.
+// If the key is not present, error is returned (see `Get`).
+// This function locks mutex for its operations.
+func (s *Skhron[V]) GetWithVersion(key string) (V, uint64, error) {
+	s.mu.RLock()
+	v, ok := s.Data.Get2(key)
+	version := s.versions[key]
+	sliding := ok && s.sliding(key)
+	err := s.missing(key, ok)
+	s.mu.RUnlock()
+
+	if sliding {
+		s.touch(key)
+	}
+	if ok {
+		return v, version, nil
+	}
+
+	return *new(V), 0, err
+}
+
+// PutIfVersion is a function which puts a value under a key only if current version
+// of the key equals `expected`. Zero `expected` version means the key must not be present.
+// If `ttl` is positive, the key expires after `ttl`, otherwise it is put like with `Put`.
+// If the key is new and the storage is full (skhron.WithMaxKeys option), ErrKeyLimit is returned.
+// It returns the new version of the key.
+// On mismatch, current version of the key and ErrVersionMismatch are returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) PutIfVersion(key string, value V, ttl time.Duration, expected uint64) (uint64, error) {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current := s.versions[key]; current != expected {
+		return current, ErrVersionMismatch
+	}
+
+	if err := s.admit(key); err != nil {
+		return 0, err
+	}
+
+	s.set(key, value)
+	if ttl > 0 {
+		s.expireTTL(key, s.Clock.Now(), ttl, s.TTLJitter)
+	} else {
+		s.scheduleDefault(key, s.Clock.Now())
+	}
+
+	return s.versions[key], nil
+}
+
+// DeleteIfVersion is a function which deletes a key only if current version of the key equals `expected`.
+// On mismatch, ErrVersionMismatch is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) DeleteIfVersion(key string, expected uint64) error {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.versions[key] != expected {
+		return ErrVersionMismatch
+	}
+
+	s.remove(key, EventDelete)
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package skhron
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVersions(t *testing.T) {
+	s := New[string]()
+
+	v1, err := s.PutIfVersion("key", "first", 0, 0)
+	if err != nil || v1 == 0 {
+		t.Fatalf("PutIfVersion() on missing key = %d, %v", v1, err)
+	}
+
+	if _, err := s.PutIfVersion("key", "again", 0, 0); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("PutIfVersion() on existing key error = %v, want %v", err, ErrVersionMismatch)
+	}
+
+	value, version, err := s.GetWithVersion("key")
+	if err != nil || value != "first" || version != v1 {
+		t.Errorf("GetWithVersion() = %v, %d, %v, want first, %d", value, version, err, v1)
+	}
+
+	s.Put("other", "value") // versions are global and never repeat
+
+	v2, err := s.PutIfVersion("key", "second", time.Hour, v1)
+	if err != nil || v2 <= v1 {
+		t.Errorf("PutIfVersion() = %d, %v, want version greater than %d", v2, err, v1)
+	}
+	if _, ok := s.ttlIndex["key"]; !ok {
+		t.Errorf("PutIfVersion() did not set TTL")
+	}
+
+	if current, err := s.PutIfVersion("key", "stale", 0, v1); !errors.Is(err, ErrVersionMismatch) || current != v2 {
+		t.Errorf("PutIfVersion() with stale version = %d, %v, want %d, %v", current, err, v2, ErrVersionMismatch)
+	}
+
+	if err := s.DeleteIfVersion("key", v1); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("DeleteIfVersion() with stale version error = %v", err)
+	}
+	if err := s.DeleteIfVersion("key", v2); err != nil || s.Exists("key") {
+		t.Errorf("DeleteIfVersion() error = %v", err)
+	}
+
+	if _, _, err := s.GetWithVersion("key"); !errors.Is(err, ErrNoSuchKey) {
+		t.Errorf("GetWithVersion() on deleted key error = %v, want %v", err, ErrNoSuchKey)
+	}
+}
+
+func TestVersionsSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	opts := []StorageOpt[string]{WithSnapshotDir[string](dir), WithTempSnapshotDir[string](dir)}
+
+	s := New(opts...)
+	s.Put("a", "1")
+	s.Put("b", "2")
+	s.Put("a", "3")
+	_, version, _ := s.GetWithVersion("a")
+
+	if err := s.CreateSnapshot(); err != nil {
+		t.Fatalf("create snapshot failed: %v", err)
+	}
+
+	loaded := New(opts...)
+	if err := loaded.LoadSnapshot(); err != nil {
+		t.Fatalf("load snapshot failed: %v", err)
+	}
+
+	if _, got, _ := loaded.GetWithVersion("a"); got != version {
+		t.Errorf("version after load = %d, want %d", got, version)
+	}
+
+	loaded.Put("c", "4")
+	if _, got, _ := loaded.GetWithVersion("c"); got <= version {
+		t.Errorf("version after load = %d, want greater than %d", got, version)
+	}
+}
+
+func TestVersionsSnapshotEvents(t *testing.T) {
+	dir := t.TempDir()
+	opts := []StorageOpt[string]{WithSnapshotDir[string](dir), WithTempSnapshotDir[string](dir)}
+
+	s := New(opts...)
+	s.Put("a", "1")
+	s.Put("a", "2")
+	if err := s.CreateSnapshot(); err != nil {
+		t.Fatalf("create snapshot failed: %v", err)
+	}
+
+	loaded := New(opts...)
+	loaded.Put("stale", "0")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := loaded.Watch(ctx, nil)
+
+	if err := loaded.LoadSnapshot(); err != nil {
+		t.Fatalf("load snapshot failed: %v", err)
+	}
+
+	if e := receive(t, events); e.Type != EventEvict || e.Key != "stale" {
+		t.Errorf("event = %+v, want evict of \"stale\"", e)
+	}
+
+	// the event carries the restored version, so it can be used for conditional writes
+	e := receive(t, events)
+	if _, version, _ := loaded.GetWithVersion("a"); e.Type != EventPut || e.Key != "a" || e.Version != version {
+		t.Errorf("event = %+v, want put of \"a\" with version %d", e, version)
+	}
+	if _, err := loaded.PutIfVersion("a", "3", 0, e.Version); err != nil {
+		t.Errorf("PutIfVersion() with the version of the event = %v", err)
+	}
+
+	if stats := loaded.Stats(); stats.Puts != 2 || stats.Evicted != 1 {
+		t.Errorf("Stats() = %+v, want 2 puts and 1 eviction", stats)
+	}
+}
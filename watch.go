@@ -0,0 +1,98 @@
+package skhron
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// EventType is a type of a change of a key.
+type EventType int
+
+const (
+	// EventPut is emitted when a value is put under the key.
+	EventPut EventType = iota + 1
+	// EventDelete is emitted when the key is deleted.
+	EventDelete
+	// EventExpire is emitted when the key is removed by cleanup after its TTL.
+	EventExpire
+	// EventEvict is emitted when the key is removed by the storage itself (e.g. flushed).
+	EventEvict
+	// EventNegative is emitted when the key is replaced with a negative entry (see `PutNegative`).
+	EventNegative
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventPut:
+		return "put"
+	case EventDelete:
+		return "delete"
+	case EventExpire:
+		return "expire"
+	case EventEvict:
+		return "evict"
+	case EventNegative:
+		return "negative"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a change of a key.
+type Event[V any] struct {
+	Type EventType
+	Key  string
+	// The new value for EventPut, the last value for other events
+	Value V
+	// The new version for EventPut, the last version for other events
+	Version uint64
+}
+
+// KeyFilter is a function which selects keys, e.g. for Skhron.Watch.
+// Nil filter selects all keys.
+type KeyFilter func(key string) bool
+
+// MatchKey is a function which creates a filter selecting a single key.
+func MatchKey(key string) KeyFilter {
+	return func(k string) bool { return k == key }
+}
+
+// MatchPrefix is a function which creates a filter selecting keys with the prefix.
+func MatchPrefix(prefix string) KeyFilter {
+	return func(key string) bool { return strings.HasPrefix(key, prefix) }
+}
+
+// MatchRegex is a function which creates a filter selecting keys matching the regex.
+func MatchRegex(mask *regexp.Regexp) KeyFilter {
+	return mask.MatchString
+}
+
+// MatchGlob is a function which creates a filter selecting keys matching the glob pattern
+// (see Skhron.FindGlob for the syntax).
+func MatchGlob(pattern string) KeyFilter {
+	return func(key string) bool { return matchGlob(pattern, key) }
+}
+
+// Watch is a function which subscribes to changes of keys selected by the filter.
+// Events are delivered in the order of changes after the storage lock is released,
+// so a slow subscriber never blocks readers.
+// Each subscriber has a bounded buffer (skhron.WithBuffer option); when it is full,
+// the overflow policy is applied (skhron.WithOverflow option): the event is dropped (default),
+// the event is queued until the subscriber reads it (up to a limit), or the subscriber is disconnected.
+// The channel is closed when `ctx` is done or the subscriber is disconnected.
+func (s *Skhron[V]) Watch(ctx context.Context, filter KeyFilter, opts ...SubscribeOpt) <-chan Event[V] {
+	match := func(e Event[V]) bool { return true }
+	if filter != nil {
+		match = func(e Event[V]) bool { return filter(e.Key) }
+	}
+
+	return s.events.subscribe(ctx, match, opts).ch
+}
+
+// dispatch is a function which delivers events queued by writes.
+// Mutating functions defer it before locking the mutex,
+// so it runs after the mutex is unlocked.
+func (s *Skhron[V]) dispatch() {
+	s.events.dispatch()
+}
@@ -0,0 +1,190 @@
+package skhron
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// receive is a function which reads an event or fails the test after a second.
+func receive[V any](t *testing.T, ch <-chan Event[V]) Event[V] {
+	t.Helper()
+
+	select {
+	case e, ok := <-ch:
+		if !ok {
+			t.Fatalf("channel is closed")
+		}
+		return e
+	case <-time.After(time.Second):
+		t.Fatalf("no event received")
+	}
+
+	return Event[V]{}
+}
+
+func TestWatch(t *testing.T) {
+	s := New[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events := s.Watch(ctx, MatchPrefix("user:"))
+
+	s.Put("user:1", 1)
+	s.Put("group:1", 2) // filtered out
+	s.PutTTL("user:2", 3, -time.Second)
+	s.Delete("user:1")
+	s.Delete("user:missing") // no event for missing keys
+	s.CleanUp()
+
+	want := []Event[int]{
+		{Type: EventPut, Key: "user:1", Value: 1},
+		{Type: EventPut, Key: "user:2", Value: 3},
+		{Type: EventDelete, Key: "user:1", Value: 1},
+		{Type: EventExpire, Key: "user:2", Value: 3},
+	}
+
+	for _, w := range want {
+		e := receive(t, events)
+		if e.Type != w.Type || e.Key != w.Key || e.Value != w.Value || e.Version == 0 {
+			t.Errorf("event = %+v, want %+v", e, w)
+		}
+	}
+
+	cancel()
+	select {
+	case e, ok := <-events:
+		if ok {
+			t.Errorf("unexpected event after cancel: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("channel is not closed after cancel")
+	}
+}
+
+func TestWatchOverflow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("drop", func(t *testing.T) {
+		s := New[int]()
+		events := s.Watch(ctx, nil, WithBuffer(2))
+
+		for i := 0; i < 5; i++ {
+			s.Put("key", i)
+		}
+
+		if e := receive(t, events); e.Value != 0 {
+			t.Errorf("first event value = %d, want 0", e.Value)
+		}
+		if e := receive(t, events); e.Value != 1 {
+			t.Errorf("second event value = %d, want 1", e.Value)
+		}
+		if len(events) != 0 {
+			t.Errorf("overflowing events were not dropped")
+		}
+	})
+
+	t.Run("disconnect", func(t *testing.T) {
+		s := New[int]()
+		events := s.Watch(ctx, nil, WithBuffer(1), WithOverflow(OverflowDisconnect))
+
+		s.Put("key", 1)
+		s.Put("key", 2)
+
+		receive(t, events)
+		if _, ok := <-events; ok {
+			t.Errorf("subscriber was not disconnected")
+		}
+	})
+
+	t.Run("disconnect without cancel", func(t *testing.T) {
+		s := New[int]()
+		before := runtime.NumGoroutine()
+
+		// disconnected subscribers do not wait for their contexts, which are never done
+		for i := 0; i < 10; i++ {
+			s.Watch(context.Background(), nil, WithBuffer(0), WithOverflow(OverflowDisconnect))
+		}
+		s.Put("key", 1)
+
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > before {
+			if time.Now().After(deadline) {
+				t.Fatalf("goroutines of disconnected subscribers are leaked: %d, want %d", runtime.NumGoroutine(), before)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+
+	t.Run("queue", func(t *testing.T) {
+		s := New[int]()
+		events := s.Watch(ctx, nil, WithBuffer(0), WithOverflow(OverflowQueue))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 3; i++ {
+				s.Put("key", i)
+			}
+		}()
+
+		for i := 0; i < 3; i++ {
+			e := receive(t, events)
+			if e.Value != i {
+				t.Errorf("event value = %d, want %d", e.Value, i)
+			}
+			// the storage is not locked while events wait for the subscriber
+			if _, err := s.Get("key"); err != nil {
+				t.Errorf("get failed: %v", err)
+			}
+		}
+
+		<-done
+	})
+
+	t.Run("queue limit", func(t *testing.T) {
+		s := New[int]()
+		events := s.Watch(ctx, nil, WithBuffer(0), WithOverflow(OverflowQueue), WithQueueLimit(2))
+
+		for i := 0; i < 3; i++ {
+			s.Put("key", i)
+		}
+
+		// the subscriber is disconnected instead of growing its queue
+		received := 0
+		for {
+			select {
+			case _, ok := <-events:
+				if ok {
+					received++
+					continue
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("subscriber with full queue was not disconnected")
+			}
+			break
+		}
+		if received >= 3 {
+			t.Errorf("received %d events with queue limit 2", received)
+		}
+	})
+
+	t.Run("queue with writing subscriber", func(t *testing.T) {
+		s := New[int]()
+		events := s.Watch(ctx, MatchKey("key"), WithBuffer(1), WithOverflow(OverflowQueue))
+
+		for i := 0; i < 3; i++ {
+			s.Put("key", i)
+		}
+
+		// the subscriber writes while the events are still pending
+		for i := 0; i < 3; i++ {
+			e := receive(t, events)
+			if e.Value != i {
+				t.Errorf("event value = %d, want %d", e.Value, i)
+			}
+			s.Put("other", i)
+		}
+	})
+}
@@ -0,0 +1,268 @@
+package skhron
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// ErrInvalidScore is returned (wrapped with the key) when a score of a sorted set member is NaN.
+var ErrInvalidScore = errors.New("invalid score")
+
+// ZMember is a member of a sorted set with its score.
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// sortedSet is a set of members ordered by scores (and by members for equal scores).
+type sortedSet struct {
+	scores map[string]float64
+	sorted []ZMember
+}
+
+func newSortedSet() *sortedSet {
+	return &sortedSet{scores: make(map[string]float64)}
+}
+
+func compareZMembers(a, b ZMember) int {
+	if c := cmp.Compare(a.Score, b.Score); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Member, b.Member)
+}
+
+func (z *sortedSet) len() int {
+	return len(z.sorted)
+}
+
+// add is a function which sets the score of the member.
+// It returns true if the member is new and true if the set was changed (the member is new or its score differs).
+func (z *sortedSet) add(member string, score float64) (added, changed bool) {
+	old, exists := z.scores[member]
+	if exists {
+		if old == score {
+			return false, false
+		}
+		z.delete(member)
+	}
+
+	z.scores[member] = score
+	e := ZMember{Member: member, Score: score}
+	i, _ := slices.BinarySearchFunc(z.sorted, e, compareZMembers)
+	z.sorted = slices.Insert(z.sorted, i, e)
+
+	return !exists, true
+}
+
+// delete is a function which removes the member. It returns true if the member was present.
+func (z *sortedSet) delete(member string) bool {
+	score, ok := z.scores[member]
+	if !ok {
+		return false
+	}
+
+	delete(z.scores, member)
+	i, _ := slices.BinarySearchFunc(z.sorted, ZMember{Member: member, Score: score}, compareZMembers)
+	z.sorted = slices.Delete(z.sorted, i, i+1)
+
+	return true
+}
+
+// rangeByScore is a function which returns members with scores in [min, max] in ascending order.
+func (z *sortedSet) rangeByScore(min, max float64) []ZMember {
+	from, _ := slices.BinarySearchFunc(z.sorted, min, func(e ZMember, score float64) int {
+		return cmp.Compare(e.Score, score)
+	})
+
+	members := make([]ZMember, 0)
+	for _, e := range z.sorted[from:] {
+		if e.Score > max {
+			break
+		}
+		members = append(members, e)
+	}
+
+	return members
+}
+
+// ZAdd is a function which sets the score of the member of the sorted set stored under the key.
+// A missing sorted set is created.
+// It returns true if the member is new.
+// Infinite scores are allowed, if the score is NaN, ErrInvalidScore is returned.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) ZAdd(key string, score float64, member string) (bool, error) {
+	if math.IsNaN(score) {
+		return false, invalidScore(key, member)
+	}
+
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.writeCollection(key, CollectionZSet, true)
+	if err != nil {
+		return false, err
+	}
+
+	added, changed := c.zset.add(member, score)
+	if changed {
+		s.changed(key, c)
+	}
+
+	return added, nil
+}
+
+// ZIncrBy is a function which adds `delta` to the score of the member of the sorted set stored under the key.
+// A missing member starts from zero score, a missing sorted set is created.
+// It returns the new score.
+// If the new score is NaN (e.g. `delta` is NaN or the opposite infinity of the score),
+// the member is not changed and ErrInvalidScore is returned.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) ZIncrBy(key string, delta float64, member string) (float64, error) {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if math.IsNaN(delta) {
+		return 0, invalidScore(key, member)
+	}
+
+	c, err := s.writeCollection(key, CollectionZSet, true)
+	if err != nil {
+		return 0, err
+	}
+
+	score := c.zset.scores[member] + delta
+	if math.IsNaN(score) {
+		return c.zset.scores[member], invalidScore(key, member)
+	}
+	if _, changed := c.zset.add(member, score); changed {
+		s.changed(key, c)
+	}
+
+	return score, nil
+}
+
+// ZRem is a function which removes members from the sorted set stored under the key.
+// The sorted set is removed when it becomes empty.
+// It returns the number of removed members.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex for its operations.
+func (s *Skhron[V]) ZRem(key string, members ...string) (int, error) {
+	defer s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.writeCollection(key, CollectionZSet, false)
+	if err != nil || c == nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, member := range members {
+		if c.zset.delete(member) {
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		s.changed(key, c)
+	}
+
+	return removed, nil
+}
+
+// ZScore is a function which returns the score of the member of the sorted set stored under the key.
+// If the sorted set or the member is missing, ErrNoSuchKey is returned,
+// if the key holds another type, ErrWrongType is returned.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) ZScore(key, member string) (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.readCollection(key, CollectionZSet)
+	if err != nil {
+		return 0, err
+	}
+	if c == nil {
+		return 0, noSuchKey(key)
+	}
+
+	score, ok := c.zset.scores[member]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s (member %s)", ErrNoSuchKey, key, member)
+	}
+
+	return score, nil
+}
+
+// ZRangeByScore is a function which returns members of the sorted set stored under the key
+// with scores from `min` to `max` inclusive, ordered by scores (members with equal scores are ordered lexicographically).
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) ZRangeByScore(key string, min, max float64) ([]ZMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.readCollection(key, CollectionZSet)
+	if err != nil || c == nil {
+		return []ZMember{}, err
+	}
+
+	return c.zset.rangeByScore(min, max), nil
+}
+
+// ZCard is a function which returns the number of members of the sorted set stored under the key.
+// If the key holds another type, ErrWrongType is returned.
+// This function locks mutex (for reading) for its operations.
+func (s *Skhron[V]) ZCard(key string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.readCollection(key, CollectionZSet)
+	if err != nil || c == nil {
+		return 0, err
+	}
+
+	return c.zset.len(), nil
+}
+
+// invalidScore is a function which creates an error for a NaN score of a member of a sorted set.
+func invalidScore(key, member string) error {
+	return fmt.Errorf("%w: %s (member %s)", ErrInvalidScore, key, member)
+}
+
+// snapshotScore is a score in the snapshot. JSON has no infinities, so they are encoded as strings.
+type snapshotScore float64
+
+func (score snapshotScore) MarshalJSON() ([]byte, error) {
+	if math.IsInf(float64(score), 0) {
+		return json.Marshal(strconv.FormatFloat(float64(score), 'g', -1, 64))
+	}
+	return json.Marshal(float64(score))
+}
+
+func (score *snapshotScore) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return json.Unmarshal(data, (*float64)(score))
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return err
+	}
+	if math.IsNaN(value) {
+		return ErrInvalidScore
+	}
+	*score = snapshotScore(value)
+
+	return nil
+}